@@ -0,0 +1,529 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"iter8.tools/iter8-controller/api/v1alpha2"
+)
+
+// conversionAnnotation keeps the v1alpha1 values that have no v1alpha2 representation,
+// so that a v1alpha1 object converted to v1alpha2 and back is unchanged
+const conversionAnnotation = "iter8.tools/v1alpha1-conversion"
+
+// conversionData holds the values stored in conversionAnnotation
+// +kubebuilder:object:generate=false
+type conversionData struct {
+	TargetUID             types.UID `json:"targetUID,omitempty"`
+	TargetResourceVersion string    `json:"targetResourceVersion,omitempty"`
+	TargetFieldPath       string    `json:"targetFieldPath,omitempty"`
+	StartTimestamp        string    `json:"startTimestamp,omitempty"`
+	EndTimestamp          string    `json:"endTimestamp,omitempty"`
+
+	// PreviousRuns holds the timestamps of the previous runs, by index, when any of them is kept
+	PreviousRuns []runTimestamps `json:"previousRuns,omitempty"`
+}
+
+// runTimestamps holds the timestamps of a previous run which have no v1alpha2 representation
+// +kubebuilder:object:generate=false
+type runTimestamps struct {
+	StartTimestamp string `json:"startTimestamp,omitempty"`
+	EndTimestamp   string `json:"endTimestamp,omitempty"`
+}
+
+// isEmpty tells whether there is nothing to keep
+func (d *conversionData) isEmpty() bool {
+	return d.TargetUID == "" && d.TargetResourceVersion == "" && d.TargetFieldPath == "" &&
+		d.StartTimestamp == "" && d.EndTimestamp == "" && len(d.PreviousRuns) == 0
+}
+
+var _ conversion.Convertible = &Experiment{}
+
+// ConvertTo converts this Experiment to the Hub version (v1alpha2)
+func (src *Experiment) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1alpha2.Experiment)
+	data := conversionData{}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+
+	// Spec
+	if ref := src.Spec.TargetService.ObjectReference; ref != nil {
+		dst.Spec.TargetService.Ref = v1alpha2.TargetReference{
+			APIVersion: ref.APIVersion,
+			Kind:       ref.Kind,
+			Name:       ref.Name,
+			Namespace:  ref.Namespace,
+		}
+		data.TargetUID = ref.UID
+		data.TargetResourceVersion = ref.ResourceVersion
+		data.TargetFieldPath = ref.FieldPath
+	}
+	dst.Spec.TargetService.Baseline = src.Spec.TargetService.Baseline
+	dst.Spec.TargetService.Candidate = src.Spec.TargetService.Candidate
+	dst.Spec.TargetService.Candidates = copyStrings(src.Spec.TargetService.Candidates)
+
+	t := src.Spec.TrafficControl.DeepCopy()
+	dst.Spec.TrafficControl = v1alpha2.TrafficControl{
+		Strategy:             t.Strategy,
+		MaxTrafficPercentage: t.MaxTrafficPercentage,
+		TrafficStepSize:      t.TrafficStepSize,
+		Interval:             t.Interval,
+		MaxIterations:        t.MaxIterations,
+		OnSuccess:            t.OnSuccess,
+		OnDrift:              t.OnDrift,
+	}
+	if t.Steps != nil {
+		dst.Spec.TrafficControl.Steps = make([]v1alpha2.TrafficStep, len(t.Steps))
+		for i, step := range t.Steps {
+			dst.Spec.TrafficControl.Steps[i] = v1alpha2.TrafficStep(step)
+		}
+	}
+	dst.Spec.TrafficControl.Match = toHubMatch(t.Match)
+	dst.Spec.TrafficControl.Mirror = (*v1alpha2.MirrorPhase)(t.Mirror)
+	dst.Spec.TrafficControl.ApprovalGates = t.ApprovalGates
+
+	a := src.Spec.Analysis.DeepCopy()
+	dst.Spec.Analysis = v1alpha2.Analysis{
+		AnalyticsService: a.AnalyticsService,
+		GrafanaEndpoint:  a.GrafanaEndpoint,
+		FailurePolicy:    (*v1alpha2.FailurePolicy)(a.FailurePolicy),
+	}
+	if a.SuccessCriteria != nil {
+		dst.Spec.Analysis.SuccessCriteria = make([]v1alpha2.SuccessCriterion, len(a.SuccessCriteria))
+		for i, c := range a.SuccessCriteria {
+			dst.Spec.Analysis.SuccessCriteria[i] = v1alpha2.SuccessCriterion{
+				MetricName:    c.MetricName,
+				ToleranceType: v1alpha2.ToleranceType(c.ToleranceType),
+				Tolerance:     c.Tolerance,
+				SampleSize:    c.SampleSize,
+				StopOnFailure: c.StopOnFailure,
+			}
+		}
+	}
+
+	dst.Spec.Assessment = v1alpha2.AssessmentType(src.Spec.Assessment)
+	dst.Spec.Template = src.Spec.Template
+	dst.Spec.Action = v1alpha2.ActionType(src.Spec.Action)
+	dst.Spec.DryRun = src.Spec.DryRun
+	dst.Spec.RoutingProvider = src.Spec.RoutingProvider
+	dst.Spec.CleanUp = v1alpha2.CleanUpType(src.Spec.CleanUp)
+	dst.Spec.RoutingReference = src.Spec.RoutingReference.DeepCopy()
+	dst.Spec.TTLSecondsAfterFinished = src.Spec.TTLSecondsAfterFinished
+	dst.Spec.ArchiveStatus = src.Spec.ArchiveStatus
+
+	// Status
+	s := src.Status.DeepCopy()
+	dst.Status.Status = s.Status
+	dst.Status.StartTimestamp, data.StartTimestamp = toMicroTime(s.StartTimestamp)
+	dst.Status.EndTimestamp, data.EndTimestamp = toMicroTime(s.EndTimestamp)
+	dst.Status.LastIncrementTime = s.LastIncrementTime
+	dst.Status.CurrentIteration = s.CurrentIteration
+	dst.Status.AnalysisState = s.AnalysisState
+	dst.Status.GrafanaURL = s.GrafanaURL
+	dst.Status.AssessmentSummary = v1alpha2.Summary(s.AssessmentSummary)
+	dst.Status.TrafficSplit = v1alpha2.TrafficSplit(s.TrafficSplit)
+	if s.Candidates != nil {
+		dst.Status.Candidates = make([]v1alpha2.CandidateStatus, len(s.Candidates))
+		for i, c := range s.Candidates {
+			dst.Status.Candidates[i] = v1alpha2.CandidateStatus{
+				Name:              c.Name,
+				TrafficPercentage: c.TrafficPercentage,
+				AssessmentSummary: v1alpha2.Summary(c.AssessmentSummary),
+				AnalysisState:     c.AnalysisState,
+				SuccessCriteria:   toHubCriteria(c.SuccessCriteria),
+			}
+		}
+	}
+	dst.Status.Winner = s.Winner
+	if s.History != nil {
+		dst.Status.History = make([]v1alpha2.IterationRecord, len(s.History))
+		for i, h := range s.History {
+			dst.Status.History[i] = v1alpha2.IterationRecord{
+				Iteration:    h.Iteration,
+				Timestamp:    h.Timestamp,
+				TrafficSplit: v1alpha2.TrafficSplit(h.TrafficSplit),
+				Conclusions:  h.Conclusions,
+				Error:        h.Error,
+			}
+			if h.Candidates != nil {
+				dst.Status.History[i].Candidates = make([]v1alpha2.CandidateRecord, len(h.Candidates))
+				for j, c := range h.Candidates {
+					dst.Status.History[i].Candidates[j] = v1alpha2.CandidateRecord{
+						Name:              c.Name,
+						TrafficPercentage: c.TrafficPercentage,
+						SuccessCriteria:   toHubCriteria(c.SuccessCriteria),
+					}
+				}
+			}
+		}
+	}
+	dst.Status.CompactedIterations = s.CompactedIterations
+	dst.Status.TemplateGeneration = s.TemplateGeneration
+	dst.Status.RoutingSnapshot = (*v1alpha2.RoutingSnapshot)(s.RoutingSnapshot)
+	if s.PreviousRuns != nil {
+		dst.Status.PreviousRuns = make([]v1alpha2.RunSummary, len(s.PreviousRuns))
+		kept, keep := make([]runTimestamps, len(s.PreviousRuns)), false
+		for i, run := range s.PreviousRuns {
+			start, keptStart := toMicroTime(run.StartTimestamp)
+			end, keptEnd := toMicroTime(run.EndTimestamp)
+			kept[i] = runTimestamps{StartTimestamp: keptStart, EndTimestamp: keptEnd}
+			keep = keep || kept[i] != runTimestamps{}
+			dst.Status.PreviousRuns[i] = v1alpha2.RunSummary{
+				StartTimestamp: start,
+				EndTimestamp:   end,
+				Iterations:     run.Iterations,
+				TrafficSplit:   v1alpha2.TrafficSplit(run.TrafficSplit),
+				Winner:         run.Winner,
+				Conclusions:    copyStrings(run.Conclusions),
+				Message:        run.Message,
+			}
+		}
+		if keep {
+			data.PreviousRuns = kept
+		}
+	}
+	dst.Status.LastRerun = s.LastRerun
+	dst.Status.AnalyticsFailures = s.AnalyticsFailures
+	dst.Status.AnalyticsFailingSince = s.AnalyticsFailingSince.DeepCopy()
+	dst.Status.FailurePolicyAction = s.FailurePolicyAction
+	if s.Approvals != nil {
+		dst.Status.Approvals = make([]v1alpha2.Approval, len(s.Approvals))
+		for i, approval := range s.Approvals {
+			dst.Status.Approvals[i] = v1alpha2.Approval(approval)
+		}
+	}
+	dst.Status.Phase = v1alpha2.Phase(s.Phase)
+	dst.Status.Message = s.Message
+
+	// Metrics are a snapshot kept in the status in v1alpha2
+	if len(src.Metrics) > 0 {
+		names := make([]string, 0, len(src.Metrics))
+		for name := range src.Metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		dst.Status.Metrics = make([]v1alpha2.MetricSnapshot, len(names))
+		for i, name := range names {
+			m := src.Metrics[name]
+			dst.Status.Metrics[i] = v1alpha2.MetricSnapshot{
+				Name:               name,
+				QueryTemplate:      m.QueryTemplate,
+				SampleSizeTemplate: m.SampleSizeTemplate,
+				IsCounter:          m.IsCounter,
+				AbsentValue:        m.AbsentValue,
+			}
+		}
+	}
+
+	if !data.isEmpty() {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[conversionAnnotation] = string(raw)
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the Hub version (v1alpha2) to this version
+func (dst *Experiment) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1alpha2.Experiment)
+	data := conversionData{}
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if raw, ok := dst.Annotations[conversionAnnotation]; ok {
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			return err
+		}
+		delete(dst.Annotations, conversionAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	// Spec
+	ref := src.Spec.TargetService.Ref
+	if ref != (v1alpha2.TargetReference{}) || data.TargetUID != "" || data.TargetResourceVersion != "" || data.TargetFieldPath != "" {
+		dst.Spec.TargetService.ObjectReference = &corev1.ObjectReference{
+			APIVersion:      ref.APIVersion,
+			Kind:            ref.Kind,
+			Name:            ref.Name,
+			Namespace:       ref.Namespace,
+			UID:             data.TargetUID,
+			ResourceVersion: data.TargetResourceVersion,
+			FieldPath:       data.TargetFieldPath,
+		}
+	}
+	dst.Spec.TargetService.Baseline = src.Spec.TargetService.Baseline
+	dst.Spec.TargetService.Candidate = src.Spec.TargetService.Candidate
+	dst.Spec.TargetService.Candidates = copyStrings(src.Spec.TargetService.Candidates)
+
+	t := src.Spec.TrafficControl.DeepCopy()
+	dst.Spec.TrafficControl = TrafficControl{
+		Strategy:             t.Strategy,
+		MaxTrafficPercentage: t.MaxTrafficPercentage,
+		TrafficStepSize:      t.TrafficStepSize,
+		Interval:             t.Interval,
+		MaxIterations:        t.MaxIterations,
+		OnSuccess:            t.OnSuccess,
+		OnDrift:              t.OnDrift,
+	}
+	if t.Steps != nil {
+		dst.Spec.TrafficControl.Steps = make([]TrafficStep, len(t.Steps))
+		for i, step := range t.Steps {
+			dst.Spec.TrafficControl.Steps[i] = TrafficStep(step)
+		}
+	}
+	dst.Spec.TrafficControl.Match = fromHubMatch(t.Match)
+	dst.Spec.TrafficControl.Mirror = (*MirrorPhase)(t.Mirror)
+	dst.Spec.TrafficControl.ApprovalGates = t.ApprovalGates
+
+	a := src.Spec.Analysis.DeepCopy()
+	dst.Spec.Analysis = Analysis{
+		AnalyticsService: a.AnalyticsService,
+		GrafanaEndpoint:  a.GrafanaEndpoint,
+		FailurePolicy:    (*FailurePolicy)(a.FailurePolicy),
+	}
+	if a.SuccessCriteria != nil {
+		dst.Spec.Analysis.SuccessCriteria = make([]SuccessCriterion, len(a.SuccessCriteria))
+		for i, c := range a.SuccessCriteria {
+			dst.Spec.Analysis.SuccessCriteria[i] = SuccessCriterion{
+				MetricName:    c.MetricName,
+				ToleranceType: ToleranceType(c.ToleranceType),
+				Tolerance:     c.Tolerance,
+				SampleSize:    c.SampleSize,
+				StopOnFailure: c.StopOnFailure,
+			}
+		}
+	}
+
+	dst.Spec.Assessment = AssessmentType(src.Spec.Assessment)
+	dst.Spec.Template = src.Spec.Template
+	dst.Spec.Action = ActionType(src.Spec.Action)
+	dst.Spec.DryRun = src.Spec.DryRun
+	dst.Spec.RoutingProvider = src.Spec.RoutingProvider
+	dst.Spec.CleanUp = CleanUpType(src.Spec.CleanUp)
+	dst.Spec.RoutingReference = src.Spec.RoutingReference.DeepCopy()
+	dst.Spec.TTLSecondsAfterFinished = src.Spec.TTLSecondsAfterFinished
+	dst.Spec.ArchiveStatus = src.Spec.ArchiveStatus
+
+	// Status
+	s := src.Status.DeepCopy()
+	dst.Status.Status = s.Status
+	dst.Status.StartTimestamp = fromMicroTime(s.StartTimestamp, data.StartTimestamp)
+	dst.Status.EndTimestamp = fromMicroTime(s.EndTimestamp, data.EndTimestamp)
+	dst.Status.LastIncrementTime = s.LastIncrementTime
+	dst.Status.CurrentIteration = s.CurrentIteration
+	dst.Status.AnalysisState = s.AnalysisState
+	dst.Status.GrafanaURL = s.GrafanaURL
+	dst.Status.AssessmentSummary = Summary(s.AssessmentSummary)
+	dst.Status.TrafficSplit = TrafficSplit(s.TrafficSplit)
+	if s.Candidates != nil {
+		dst.Status.Candidates = make([]CandidateStatus, len(s.Candidates))
+		for i, c := range s.Candidates {
+			dst.Status.Candidates[i] = CandidateStatus{
+				Name:              c.Name,
+				TrafficPercentage: c.TrafficPercentage,
+				AssessmentSummary: Summary(c.AssessmentSummary),
+				AnalysisState:     c.AnalysisState,
+				SuccessCriteria:   fromHubCriteria(c.SuccessCriteria),
+			}
+		}
+	}
+	dst.Status.Winner = s.Winner
+	if s.History != nil {
+		dst.Status.History = make([]IterationRecord, len(s.History))
+		for i, h := range s.History {
+			dst.Status.History[i] = IterationRecord{
+				Iteration:    h.Iteration,
+				Timestamp:    h.Timestamp,
+				TrafficSplit: TrafficSplit(h.TrafficSplit),
+				Conclusions:  h.Conclusions,
+				Error:        h.Error,
+			}
+			if h.Candidates != nil {
+				dst.Status.History[i].Candidates = make([]CandidateRecord, len(h.Candidates))
+				for j, c := range h.Candidates {
+					dst.Status.History[i].Candidates[j] = CandidateRecord{
+						Name:              c.Name,
+						TrafficPercentage: c.TrafficPercentage,
+						SuccessCriteria:   fromHubCriteria(c.SuccessCriteria),
+					}
+				}
+			}
+		}
+	}
+	dst.Status.CompactedIterations = s.CompactedIterations
+	dst.Status.TemplateGeneration = s.TemplateGeneration
+	dst.Status.RoutingSnapshot = (*RoutingSnapshot)(s.RoutingSnapshot)
+	if s.PreviousRuns != nil {
+		dst.Status.PreviousRuns = make([]RunSummary, len(s.PreviousRuns))
+		for i, run := range s.PreviousRuns {
+			kept := runTimestamps{}
+			if i < len(data.PreviousRuns) {
+				kept = data.PreviousRuns[i]
+			}
+			dst.Status.PreviousRuns[i] = RunSummary{
+				StartTimestamp: fromMicroTime(run.StartTimestamp, kept.StartTimestamp),
+				EndTimestamp:   fromMicroTime(run.EndTimestamp, kept.EndTimestamp),
+				Iterations:     run.Iterations,
+				TrafficSplit:   TrafficSplit(run.TrafficSplit),
+				Winner:         run.Winner,
+				Conclusions:    copyStrings(run.Conclusions),
+				Message:        run.Message,
+			}
+		}
+	}
+	dst.Status.LastRerun = s.LastRerun
+	dst.Status.AnalyticsFailures = s.AnalyticsFailures
+	dst.Status.AnalyticsFailingSince = s.AnalyticsFailingSince.DeepCopy()
+	dst.Status.FailurePolicyAction = s.FailurePolicyAction
+	if s.Approvals != nil {
+		dst.Status.Approvals = make([]Approval, len(s.Approvals))
+		for i, approval := range s.Approvals {
+			dst.Status.Approvals[i] = Approval(approval)
+		}
+	}
+	dst.Status.Phase = Phase(s.Phase)
+	dst.Status.Message = s.Message
+
+	if len(s.Metrics) > 0 {
+		dst.Metrics = make(ExperimentMetrics, len(s.Metrics))
+		for _, m := range s.Metrics {
+			dst.Metrics[m.Name] = ExperimentMetric{
+				QueryTemplate:      m.QueryTemplate,
+				SampleSizeTemplate: m.SampleSizeTemplate,
+				IsCounter:          m.IsCounter,
+				AbsentValue:        m.AbsentValue,
+			}
+		}
+	}
+
+	return nil
+}
+
+// toMicroTime parses a v1alpha1 timestamp, in milliseconds since the epoch.
+// A timestamp which does not convert back to the same string is returned as is to be kept aside.
+func toMicroTime(ts string) (*metav1.MicroTime, string) {
+	if ts == "" {
+		return nil, ""
+	}
+	ms, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || strconv.FormatInt(ms, 10) != ts {
+		return nil, ts
+	}
+	t := metav1.NewMicroTime(time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond)).UTC())
+	// RFC 3339, used to serialize MicroTime, is limited to four digit years
+	if t.Year() < 0 || t.Year() > 9999 {
+		return nil, ts
+	}
+	return &t, ""
+}
+
+// fromMicroTime formats a v1alpha2 timestamp in milliseconds since the epoch.
+// The timestamp kept aside when converting from v1alpha1, if any, is used when t is unset.
+func fromMicroTime(t *metav1.MicroTime, kept string) string {
+	if t == nil {
+		return kept
+	}
+	return strconv.FormatInt(t.Unix()*1000+int64(t.Nanosecond())/int64(time.Millisecond), 10)
+}
+
+func toHubCriteria(in []CriterionOutcome) []v1alpha2.CriterionOutcome {
+	if in == nil {
+		return nil
+	}
+	out := make([]v1alpha2.CriterionOutcome, len(in))
+	for i, c := range in {
+		out[i] = v1alpha2.CriterionOutcome(c)
+	}
+	return out
+}
+
+func fromHubCriteria(in []v1alpha2.CriterionOutcome) []CriterionOutcome {
+	if in == nil {
+		return nil
+	}
+	out := make([]CriterionOutcome, len(in))
+	for i, c := range in {
+		out[i] = CriterionOutcome(c)
+	}
+	return out
+}
+
+func toHubMatch(in []MatchRule) []v1alpha2.MatchRule {
+	if in == nil {
+		return nil
+	}
+	out := make([]v1alpha2.MatchRule, len(in))
+	for i, m := range in {
+		out[i] = v1alpha2.MatchRule{
+			Candidate: m.Candidate,
+			Cookie:    (*v1alpha2.CookieMatch)(m.Cookie),
+			URIPrefix: m.URIPrefix,
+		}
+		if m.Headers != nil {
+			out[i].Headers = make([]v1alpha2.HeaderMatch, len(m.Headers))
+			for j, h := range m.Headers {
+				out[i].Headers[j] = v1alpha2.HeaderMatch(h)
+			}
+		}
+	}
+	return out
+}
+
+func fromHubMatch(in []v1alpha2.MatchRule) []MatchRule {
+	if in == nil {
+		return nil
+	}
+	out := make([]MatchRule, len(in))
+	for i, m := range in {
+		out[i] = MatchRule{
+			Candidate: m.Candidate,
+			Cookie:    (*CookieMatch)(m.Cookie),
+			URIPrefix: m.URIPrefix,
+		}
+		if m.Headers != nil {
+			out[i].Headers = make([]HeaderMatch, len(m.Headers))
+			for j, h := range m.Headers {
+				out[i].Headers[j] = HeaderMatch(h)
+			}
+		}
+	}
+	return out
+}
+
+func copyStrings(in []string) []string {
+	if in == nil {
+		return nil
+	}
+	out := make([]string, len(in))
+	copy(out, in)
+	return out
+}
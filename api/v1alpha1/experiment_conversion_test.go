@@ -0,0 +1,111 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+
+	"iter8.tools/iter8-controller/api/v1alpha2"
+)
+
+func TestConvertRoundTrip(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	exp := newTestExperiment()
+	exp.Spec.TargetService.UID = "1234"
+	exp.Spec.TargetService.Candidates = []string{"reviews-v3"}
+	exp.Spec.Analysis.SuccessCriteria = []SuccessCriterion{
+		{MetricName: "iter8_latency", ToleranceType: ToleranceTypeThreshold, Tolerance: 0.2},
+	}
+	exp.Metrics = ExperimentMetrics{
+		"iter8_latency":    {QueryTemplate: "latency", SampleSizeTemplate: "count", AbsentValue: "None"},
+		"iter8_error_rate": {QueryTemplate: "errors", SampleSizeTemplate: "count", IsCounter: true},
+	}
+	exp.Status.StartTimestamp = "1580000000123"
+	exp.Status.EndTimestamp = "not a timestamp"
+	exp.Status.AnalysisState = runtime.RawExtension{Raw: []byte(`{"state":1}`)}
+	exp.Status.GetCandidateStatus("reviews-v2").TrafficPercentage = 20
+	exp.Status.Phase = PhaseProgressing
+
+	hub := &v1alpha2.Experiment{}
+	g.Expect(exp.ConvertTo(hub)).To(gomega.Succeed())
+	g.Expect(hub.Spec.TargetService.Ref).To(gomega.Equal(v1alpha2.TargetReference{APIVersion: "v1", Name: "reviews"}))
+	g.Expect(hub.Status.StartTimestamp.Time.Equal(time.Unix(1580000000, 123*int64(time.Millisecond)))).To(gomega.BeTrue())
+	g.Expect(hub.Status.EndTimestamp).To(gomega.BeNil())
+	g.Expect(hub.Status.Metrics).To(gomega.HaveLen(2))
+	g.Expect(hub.Status.Metrics[0].Name).To(gomega.Equal("iter8_error_rate"))
+	g.Expect(hub.Annotations).To(gomega.HaveKey(conversionAnnotation))
+
+	out := &Experiment{}
+	g.Expect(out.ConvertFrom(hub)).To(gomega.Succeed())
+	g.Expect(equality.Semantic.DeepEqual(exp, out)).To(gomega.BeTrue())
+}
+
+func TestConvertFromHub(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	start := metav1.NewMicroTime(time.Unix(1580000000, 0))
+	hub := &v1alpha2.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2-rollout", Namespace: "bookinfo"},
+		Spec: v1alpha2.ExperimentSpec{
+			TargetService: v1alpha2.TargetService{
+				Ref:       v1alpha2.TargetReference{APIVersion: "v1", Name: "reviews"},
+				Baseline:  "reviews-v1",
+				Candidate: "reviews-v2",
+			},
+		},
+		Status: v1alpha2.ExperimentStatus{StartTimestamp: &start},
+	}
+
+	exp := &Experiment{}
+	g.Expect(exp.ConvertFrom(hub)).To(gomega.Succeed())
+	g.Expect(exp.Spec.TargetService.Name).To(gomega.Equal("reviews"))
+	g.Expect(exp.Status.StartTimestamp).To(gomega.Equal("1580000000000"))
+	g.Expect(exp.Status.EndTimestamp).To(gomega.BeEmpty())
+
+	out := &v1alpha2.Experiment{}
+	g.Expect(exp.ConvertTo(out)).To(gomega.Succeed())
+	g.Expect(equality.Semantic.DeepEqual(hub, out)).To(gomega.BeTrue())
+}
+
+func TestConvertPreviousRuns(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	exp := newTestExperiment()
+	exp.Status.PreviousRuns = []RunSummary{
+		{StartTimestamp: "1580000000123", EndTimestamp: "1580000060000", Iterations: 4,
+			TrafficSplit: TrafficSplit{Baseline: 0, Candidate: 100}, Winner: "reviews-v2"},
+		{StartTimestamp: "not a timestamp", Iterations: 2, Conclusions: []string{"aborted"}},
+	}
+
+	hub := &v1alpha2.Experiment{}
+	g.Expect(exp.ConvertTo(hub)).To(gomega.Succeed())
+	g.Expect(hub.Status.PreviousRuns).To(gomega.HaveLen(2))
+	g.Expect(hub.Status.PreviousRuns[0].StartTimestamp.Time.Equal(time.Unix(1580000000, 123*int64(time.Millisecond)))).To(gomega.BeTrue())
+	g.Expect(hub.Status.PreviousRuns[1].StartTimestamp).To(gomega.BeNil())
+	g.Expect(hub.Annotations).To(gomega.HaveKey(conversionAnnotation))
+
+	out := &Experiment{}
+	g.Expect(out.ConvertFrom(hub)).To(gomega.Succeed())
+	g.Expect(out.Status.PreviousRuns[1].StartTimestamp).To(gomega.Equal("not a timestamp"))
+	g.Expect(equality.Semantic.DeepEqual(exp, out)).To(gomega.BeTrue())
+}
@@ -0,0 +1,113 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ExperimentDefaults holds the values used for the TrafficControl and Analysis fields left unset
+// +kubebuilder:object:generate=false
+type ExperimentDefaults struct {
+	Strategy             string  `yaml:"strategy"`
+	MaxTrafficPercentage float64 `yaml:"maxTrafficPercentage"`
+	TrafficStepSize      float64 `yaml:"trafficStepSize"`
+	Interval             string  `yaml:"interval"`
+	MaxIterations        int     `yaml:"maxIterations"`
+	OnSuccess            string  `yaml:"onSuccess"`
+	AnalyticsService     string  `yaml:"analyticsService"`
+	GrafanaEndpoint      string  `yaml:"grafanaEndpoint"`
+	SampleSize           int     `yaml:"sampleSize"`
+
+	// TTLSecondsAfterFinished is the time completed experiments are kept; nil keeps them until deleted
+	TTLSecondsAfterFinished *int32 `yaml:"ttlSecondsAfterFinished"`
+	ArchiveStatus           bool   `yaml:"archiveStatus"`
+}
+
+// Defaults are the cluster-wide experiment defaults, applied by the defaulting webhook and the getters.
+// They can be overridden when the manager starts with LoadDefaults.
+var Defaults = ExperimentDefaults{
+	Strategy:             StrategyCheckAndIncrement,
+	MaxTrafficPercentage: 50,
+	TrafficStepSize:      2,
+	Interval:             "1m",
+	MaxIterations:        100,
+	OnSuccess:            "candidate",
+	AnalyticsService:     "http://iter8-analytics.iter8",
+	GrafanaEndpoint:      "http://localhost:3000",
+	SampleSize:           10,
+}
+
+// LoadDefaults overrides Defaults with the values set in the YAML file at path.
+// Values not present in the file keep their built-in default.
+func LoadDefaults(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	defaults := Defaults
+	if err := yaml.UnmarshalStrict(data, &defaults); err != nil {
+		return err
+	}
+	if err := defaults.validate(); err != nil {
+		return fmt.Errorf("invalid experiment defaults in %s: %v", path, err)
+	}
+
+	Defaults = defaults
+	return nil
+}
+
+func (d *ExperimentDefaults) validate() error {
+	switch d.Strategy {
+	case StrategyCheckAndIncrement, StrategyIncrementWithoutCheck, StrategyEpsilonGreedy:
+	default:
+		return fmt.Errorf("unknown strategy %q", d.Strategy)
+	}
+	if d.MaxTrafficPercentage <= 0 || d.MaxTrafficPercentage > 100 {
+		return fmt.Errorf("maxTrafficPercentage must be greater than 0 and at most 100")
+	}
+	if d.TrafficStepSize <= 0 {
+		return fmt.Errorf("trafficStepSize must be greater than 0")
+	}
+	if interval, err := time.ParseDuration(d.Interval); err != nil {
+		return err
+	} else if interval <= 0 {
+		return fmt.Errorf("interval must be a positive duration")
+	}
+	if d.MaxIterations <= 0 {
+		return fmt.Errorf("maxIterations must be greater than 0")
+	}
+	switch d.OnSuccess {
+	case "baseline", "candidate", "both":
+	default:
+		return fmt.Errorf("unknown onSuccess %q", d.OnSuccess)
+	}
+	if d.AnalyticsService == "" {
+		return fmt.Errorf("analyticsService must not be empty")
+	}
+	if d.SampleSize <= 0 {
+		return fmt.Errorf("sampleSize must be greater than 0")
+	}
+	if d.TTLSecondsAfterFinished != nil && *d.TTLSecondsAfterFinished < 0 {
+		return fmt.Errorf("ttlSecondsAfterFinished must not be negative")
+	}
+	return nil
+}
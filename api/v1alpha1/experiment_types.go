@@ -16,6 +16,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 
 // Experiment is the Schema for the experiments API
 // +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 // +kubebuilder:categories=all,iter8
 // +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="Phase of the experiment",format="byte"
 // +kubebuilder:printcolumn:name="status",type="string",JSONPath=".status.message",description="Detailed Status of the experiment",format="byte"
@@ -61,6 +63,13 @@ const (
 	AssessmentNull            AssessmentType = ""
 )
 
+type ActionType string
+
+const (
+	ActionPause  ActionType = "pause"
+	ActionResume ActionType = "resume"
+)
+
 type CleanUpType string
 
 const (
@@ -71,6 +80,26 @@ const (
 const (
 	StrategyIncrementWithoutCheck string = "increment_without_check"
 	StrategyCheckAndIncrement     string = "check_and_increment"
+	StrategyEpsilonGreedy         string = "epsilon_greedy"
+)
+
+const (
+	OnDriftRestore string = "restore"
+	OnDriftAdopt   string = "adopt"
+	OnDriftPause   string = "pause"
+)
+
+const (
+	RoutingProviderIstio   string = "istio"
+	RoutingProviderKnative string = "knative"
+	RoutingProviderSMI     string = "smi"
+	RoutingProviderGateway string = "gateway"
+)
+
+const (
+	FailureActionHold                  string = "hold"
+	FailureActionRollback              string = "rollback"
+	FailureActionIncrementWithoutCheck string = StrategyIncrementWithoutCheck
 )
 
 // ExperimentSpec defines the desired state of Experiment
@@ -78,6 +107,11 @@ type ExperimentSpec struct {
 	// TargetService is a reference to an object to use as target service
 	TargetService TargetService `json:"targetService"`
 
+	// Template is the name of an ExperimentTemplate in the namespace of the experiment.
+	// Its trafficControl and analysis settings apply to the fields the experiment leaves unset
+	// +optional
+	Template string `json:"template,omitempty"`
+
 	// TrafficControl defines parameters for controlling the traffic
 	// +optional
 	TrafficControl TrafficControl `json:"trafficControl,omitempty"`
@@ -91,14 +125,47 @@ type ExperimentSpec struct {
 	//+kubebuilder:validation:Enum={override_success,override_failure}
 	Assessment AssessmentType `json:"assessment,omitempty"`
 
+	// Action pauses or resumes the experiment. While "pause" is set, no iteration is run and the traffic
+	// is left as is; setting "resume" or removing the action resumes the experiment.
+	// +optional
+	//+kubebuilder:validation:Enum={pause,resume}
+	Action ActionType `json:"action,omitempty"`
+
+	// DryRun runs the experiment without ever changing the routing or the targets: the traffic split of each
+	// iteration and the outcome are computed and recorded in the status and events, but not applied.
+	// It may not be changed while the experiment is running
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
 	// CleanUp is a flag to determine the action to take at the end of experiment
 	// +optional.
 	//+kubebuilder:validation:Enum=delete
 	CleanUp CleanUpType `json:"cleanup,omitempty"`
 
-	// RoutingReference provides references to routing rules set by users
+	// RoutingProvider is the routing backend driving the traffic of the target service. Options:
+	// "istio": Istio virtual services and destination rules, the default for Kubernetes services
+	// "knative": the traffic block of the Knative service, the default for Knative services
+	// "smi": an SMI TrafficSplit whose backends are the baseline and candidate services, for Kubernetes services
+	// "gateway": the backendRefs of the Gateway API HTTPRoute named by routingReference, for Kubernetes services
+	// +optional
+	//+kubebuilder:validation:Enum={istio,knative,smi,gateway}
+	RoutingProvider string `json:"routingProvider,omitempty"`
+
+	// RoutingReference provides references to routing rules set by users.
+	// The gateway routing provider drives the HTTPRoute it names, and creates it if it does not exist
 	// +optional
 	RoutingReference *corev1.ObjectReference `json:"routingReference,omitempty"`
+
+	// TTLSecondsAfterFinished is the time the experiment is kept once completed before it is deleted.
+	// Defaults to the cluster default; the experiment is kept until deleted by the user if neither is set
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// ArchiveStatus exports the final status of the experiment to a config map before it is deleted
+	// at the end of TTLSecondsAfterFinished. Defaults to the cluster default
+	// +optional
+	ArchiveStatus *bool `json:"archiveStatus,omitempty"`
 }
 
 // TargetService defines what to watch in the controller
@@ -111,6 +178,24 @@ type TargetService struct {
 
 	// Candidate tells the name of candidate
 	Candidate string `json:"candidate,omitempty"`
+
+	// Candidates tells the names of candidates when more than one version is compared against the baseline
+	// +optional
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+// GetCandidates returns the names of all candidates, with Candidate listed first if set
+func (t *TargetService) GetCandidates() []string {
+	candidates := make([]string, 0, len(t.Candidates)+1)
+	if t.Candidate != "" {
+		candidates = append(candidates, t.Candidate)
+	}
+	for _, candidate := range t.Candidates {
+		if candidate != t.Candidate {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
 }
 
 type Phase string
@@ -142,6 +227,7 @@ type ExperimentStatus struct {
 	CurrentIteration int `json:"currentIteration,omitempty"`
 
 	// AnalysisState is the last analysis state
+	// +kubebuilder:pruning:PreserveUnknownFields
 	AnalysisState runtime.RawExtension `json:"analysisState,omitempty"`
 
 	// GrafanaURL is the url to the Grafana Dashboard
@@ -153,6 +239,52 @@ type ExperimentStatus struct {
 	// TrafficSplit tells the current traffic spliting between baseline and candidate
 	TrafficSplit TrafficSplit `json:"trafficSplitPercentage,omitempty"`
 
+	// Candidates tells the current state of each candidate
+	Candidates []CandidateStatus `json:"candidates,omitempty"`
+
+	// Winner is the candidate selected at the end of the experiment
+	Winner string `json:"winner,omitempty"`
+
+	// History records the most recent iterations of the experiment; see RecordIteration
+	// +optional
+	History []IterationRecord `json:"history,omitempty"`
+
+	// CompactedIterations is the number of iterations dropped from History
+	// +optional
+	CompactedIterations int `json:"compactedIterations,omitempty"`
+
+	// TemplateGeneration is the generation of the template last merged into the effective spec
+	// +optional
+	TemplateGeneration int64 `json:"templateGeneration,omitempty"`
+
+	// Approvals records the approval gates passed so far
+	// +optional
+	Approvals []Approval `json:"approvals,omitempty"`
+
+	// RoutingSnapshot is the routing of the target service before the experiment
+	// +optional
+	RoutingSnapshot *RoutingSnapshot `json:"routingSnapshot,omitempty"`
+
+	// PreviousRuns summarizes the runs completed before the experiment was rerun, oldest first
+	// +optional
+	PreviousRuns []RunSummary `json:"previousRuns,omitempty"`
+
+	// LastRerun is the value of the iter8.tools/rerun annotation that started the current run
+	// +optional
+	LastRerun string `json:"lastRerun,omitempty"`
+
+	// AnalyticsFailures is the number of consecutive failed analyses
+	// +optional
+	AnalyticsFailures int `json:"analyticsFailures,omitempty"`
+
+	// AnalyticsFailingSince is the time of the first of the consecutive failed analyses
+	// +optional
+	AnalyticsFailingSince *metav1.Time `json:"analyticsFailingSince,omitempty"`
+
+	// FailurePolicyAction is the action of the failure policy taken once its limits were reached
+	// +optional
+	FailurePolicyAction string `json:"failurePolicyAction,omitempty"`
+
 	// Phase marks the Phase the experiment is at
 	Phase Phase `json:"phase,omitempty"`
 
@@ -160,43 +292,357 @@ type ExperimentStatus struct {
 	Message string `json:"message,omitempty"`
 }
 
+// RoutingSnapshot holds the routing of the target service before the experiment took it over,
+// restored when the experiment fails, is aborted or is deleted
+type RoutingSnapshot struct {
+	// VirtualService is the spec of the Istio virtual service
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	VirtualService *runtime.RawExtension `json:"virtualService,omitempty"`
+
+	// DestinationRule is the spec of the Istio destination rule
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	DestinationRule *runtime.RawExtension `json:"destinationRule,omitempty"`
+
+	// Traffic is the traffic block of the Knative service
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Traffic *runtime.RawExtension `json:"traffic,omitempty"`
+
+	// Backends are the backends of the SMI traffic split
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Backends *runtime.RawExtension `json:"backends,omitempty"`
+
+	// Rules are the rules of the Gateway API HTTPRoute
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Rules *runtime.RawExtension `json:"rules,omitempty"`
+}
+
+// RunSummary summarizes a completed run of the experiment
+type RunSummary struct {
+	// StartTimestamp is the timestamp when the run started
+	// +optional
+	StartTimestamp string `json:"startTimestamp,omitempty"`
+
+	// EndTimestamp is the timestamp when the run completed
+	// +optional
+	EndTimestamp string `json:"endTimestamp,omitempty"`
+
+	// Iterations is the number of iterations of the run
+	Iterations int `json:"iterations"`
+
+	// TrafficSplit tells the traffic split at the end of the run
+	TrafficSplit TrafficSplit `json:"trafficSplitPercentage"`
+
+	// Winner is the candidate selected at the end of the run
+	// +optional
+	Winner string `json:"winner,omitempty"`
+
+	// Conclusions returned by the last analysis of the run
+	// +optional
+	Conclusions []string `json:"conclusions,omitempty"`
+
+	// Message is the message of the experiment at the end of the run
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// TrafficSplit tells the traffic percentage of baseline and the total traffic percentage of candidates
 type TrafficSplit struct {
 	Baseline  int `json:"baseline"`
 	Candidate int `json:"candidate"`
 }
 
+// CandidateStatus defines the observed state of one candidate
+type CandidateStatus struct {
+	// Name of the candidate
+	Name string `json:"name"`
+
+	// TrafficPercentage is the current traffic percentage of the candidate
+	TrafficPercentage int `json:"trafficPercentage"`
+
+	// AssessmentSummary returned by the last analysis of the candidate
+	AssessmentSummary Summary `json:"assessment,omitempty"`
+
+	// AnalysisState is the last analysis state of the candidate
+	// +kubebuilder:pruning:PreserveUnknownFields
+	AnalysisState runtime.RawExtension `json:"analysisState,omitempty"`
+
+	// SuccessCriteria tells the outcome of each success criterion in the last analysis of the candidate
+	// +optional
+	SuccessCriteria []CriterionOutcome `json:"successCriteria,omitempty"`
+}
+
+// CriterionOutcome tells the outcome of a success criterion for a candidate
+type CriterionOutcome struct {
+	// MetricName is the name of the metric of the criterion
+	MetricName string `json:"metricName"`
+
+	// Met indicates whether or not the criterion is met
+	Met bool `json:"met"`
+
+	// Abort indicates whether or not the criterion requires the experiment to be aborted
+	Abort bool `json:"abort,omitempty"`
+}
+
+// IterationRecord records the outcome of one iteration of the experiment
+type IterationRecord struct {
+	// Iteration is the number of the iteration
+	Iteration int `json:"iteration"`
+
+	// Timestamp is the time when the iteration ended
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// TrafficSplit tells the traffic split at the end of the iteration
+	TrafficSplit TrafficSplit `json:"trafficSplitPercentage"`
+
+	// Candidates tells the traffic and the outcome of the success criteria of each candidate
+	// +optional
+	Candidates []CandidateRecord `json:"candidates,omitempty"`
+
+	// Conclusions returned by the analytics service
+	// +optional
+	Conclusions []string `json:"conclusions,omitempty"`
+
+	// Error that interrupted the iteration, if any
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// Approval records who approved an approval gate and when
+type Approval struct {
+	// Gate is the approved traffic percentage
+	Gate int `json:"gate"`
+
+	// Approver is the name given in the iter8.tools/approver annotation
+	// +optional
+	Approver string `json:"approver,omitempty"`
+
+	// Time when the approval was recorded
+	Time metav1.Time `json:"time"`
+}
+
+// CandidateRecord records the state of one candidate at the end of an iteration
+type CandidateRecord struct {
+	// Name of the candidate
+	Name string `json:"name"`
+
+	// TrafficPercentage is the traffic percentage of the candidate
+	TrafficPercentage int `json:"trafficPercentage"`
+
+	// SuccessCriteria tells the outcome of each success criterion for the candidate
+	// +optional
+	SuccessCriteria []CriterionOutcome `json:"successCriteria,omitempty"`
+}
+
+const (
+	// MaxIterationHistory is the maximum number of records kept in the history of iterations
+	MaxIterationHistory = 50
+
+	// MaxIterationHistoryBytes is the maximum size of the history of iterations once serialized,
+	// keeping the experiment well under the etcd object size limit
+	MaxIterationHistoryBytes = 128 * 1024
+
+	// maxRecordErrorLength is the maximum length of the error of an iteration record
+	maxRecordErrorLength = 1024
+
+	// MaxPreviousRuns is the maximum number of run summaries kept when an experiment is rerun
+	MaxPreviousRuns = 10
+)
+
+// RecordIteration appends record to the history of iterations.
+// The first iteration and the most recent ones are kept: the oldest other records are dropped when
+// the history exceeds MaxIterationHistory records or MaxIterationHistoryBytes, and counted in CompactedIterations.
+func (s *ExperimentStatus) RecordIteration(record IterationRecord) {
+	if len(record.Error) > maxRecordErrorLength {
+		record.Error = record.Error[:maxRecordErrorLength]
+	}
+	s.History = append(s.History, record)
+
+	for len(s.History) > 2 && (len(s.History) > MaxIterationHistory || historySize(s.History) > MaxIterationHistoryBytes) {
+		s.History = append(s.History[:1], s.History[2:]...)
+		s.CompactedIterations++
+	}
+}
+
+func historySize(history []IterationRecord) int {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// Rerun archives the summary of the completed run in PreviousRuns and resets the status for a new run
+// started by the rerun annotation value token. The oldest summaries are dropped beyond MaxPreviousRuns.
+func (s *ExperimentStatus) Rerun(token string) {
+	runs := append(s.PreviousRuns, RunSummary{
+		StartTimestamp: s.StartTimestamp,
+		EndTimestamp:   s.EndTimestamp,
+		Iterations:     s.CurrentIteration,
+		TrafficSplit:   s.TrafficSplit,
+		Winner:         s.Winner,
+		Conclusions:    s.AssessmentSummary.Conclusions,
+		Message:        s.Message,
+	})
+	if len(runs) > MaxPreviousRuns {
+		runs = runs[len(runs)-MaxPreviousRuns:]
+	}
+
+	*s = ExperimentStatus{
+		Status:             duckv1alpha1.Status{ObservedGeneration: s.ObservedGeneration},
+		TemplateGeneration: s.TemplateGeneration,
+		PreviousRuns:       runs,
+		LastRerun:          token,
+	}
+}
+
+// GetCandidateStatus returns the status of the named candidate, adding it if absent
+func (s *ExperimentStatus) GetCandidateStatus(name string) *CandidateStatus {
+	for i := range s.Candidates {
+		if s.Candidates[i].Name == name {
+			return &s.Candidates[i]
+		}
+	}
+	s.Candidates = append(s.Candidates, CandidateStatus{
+		Name:          name,
+		AnalysisState: runtime.RawExtension{Raw: []byte("{}")},
+	})
+	return &s.Candidates[len(s.Candidates)-1]
+}
+
 type TrafficControl struct {
 	// Strategy is the strategy used for experiment. Options:
 	// "check_and_increment": get decision on traffic increament from analytics
 	// "increment_without_check": increase traffic each interval without calling analytics
-	// +optional. Default is "check_and_increment".
+	// +optional. Defaults to the cluster default, "check_and_increment" unless configured.
 	//+kubebuilder:validation:Enum={check_and_increment,increment_without_check,epsilon_greedy}
 	Strategy *string `json:"strategy,omitempty"`
 
-	// MaxTrafficPercentage is the maximum traffic ratio to send to the candidate. Default is 50
+	// MaxTrafficPercentage is the maximum traffic ratio to send to the candidate.
+	// Defaults to the last step of Steps if set, or else to the cluster default (50)
 	// +optional
 	MaxTrafficPercentage *float64 `json:"maxTrafficPercentage,omitempty"`
 
-	// TrafficStepSize is the traffic increment per interval. Default is 2.0
+	// TrafficStepSize is the traffic increment per interval. Defaults to the cluster default (2.0)
 	// +optional
 	TrafficStepSize *float64 `json:"trafficStepSize,omitempty"`
 
-	// Interval is the time in second before the next increment. Default is 1mn
+	// Interval is the time in second before the next increment. Defaults to the cluster default (1m)
 	// +optional
 	Interval *string `json:"interval,omitempty"`
 
-	// Maximum number of iterations for this experiment. Default to 100.
+	// Maximum number of iterations for this experiment. Defaults to the cluster default (100).
 	// +optional
 	MaxIterations *int `json:"maxIterations,omitempty"`
 
+	// Steps is an explicit ramp of the total candidate traffic, used instead of TrafficStepSize.
+	// Analytics-driven strategies never go beyond the next step of the ramp.
+	// +optional
+	Steps []TrafficStep `json:"steps,omitempty"`
+
+	// Match routes user segments to candidates regardless of the traffic split. Istio targets only
+	// +optional
+	Match []MatchRule `json:"match,omitempty"`
+
+	// Mirror mirrors the baseline traffic to the candidate for the first iterations,
+	// assessed against the success criteria before any traffic is shifted. Istio targets only
+	// +optional
+	Mirror *MirrorPhase `json:"mirror,omitempty"`
+
+	// ApprovalGates are total candidate traffic percentages the ramp holds at until approved,
+	// in increasing order. See the iter8.tools/approve annotation
+	// +optional
+	ApprovalGates []int `json:"approvalGates,omitempty"`
+
 	// Determines how the traffic must be split at the end of the experiment; options:
 	// "baseline": all traffic goes to the baseline version;
 	// "candidate": all traffic goes to the candidate version;
 	// "both": traffic is split across baseline and candidate.
-	// Defaults to “candidate”
+	// Defaults to the cluster default (“candidate”)
 	// +optional
 	//+kubebuilder:validation:Enum={baseline,candidate,both}
 	OnSuccess *string `json:"onSuccess,omitempty"`
+
+	// OnDrift determines what happens when the routing rules are changed by someone else during the experiment; options:
+	// "restore": the traffic split of the experiment is restored;
+	// "adopt": the changed traffic split is recorded and the experiment goes on from it;
+	// "pause": the experiment is paused until the traffic split of the experiment is restored.
+	// Defaults to "restore"
+	// +optional
+	//+kubebuilder:validation:Enum={restore,adopt,pause}
+	OnDrift *string `json:"onDrift,omitempty"`
+}
+
+// TrafficStep is one step of a traffic ramp
+type TrafficStep struct {
+	// Percentage is the total traffic percentage of the candidates at this step
+	Percentage int `json:"percentage"`
+
+	// Dwell is the time to stay at this step before the next iteration. Defaults to Interval
+	// +optional
+	Dwell *string `json:"dwell,omitempty"`
+}
+
+// MirrorPhase mirrors baseline traffic to the candidate before the traffic ramp
+type MirrorPhase struct {
+	// Percentage of the baseline requests mirrored to the candidate. Defaults to 100
+	// +optional
+	Percentage *int `json:"percentage,omitempty"`
+
+	// Iterations run on mirrored traffic before the traffic ramp starts. Defaults to 1
+	// +optional
+	Iterations *int `json:"iterations,omitempty"`
+}
+
+// MatchRule routes the requests of a user segment to a candidate.
+// A request matches the rule when it satisfies all of its conditions.
+type MatchRule struct {
+	// Candidate receiving the matching requests. Defaults to the first candidate
+	// +optional
+	Candidate string `json:"candidate,omitempty"`
+
+	// Headers the request must carry
+	// +optional
+	Headers []HeaderMatch `json:"headers,omitempty"`
+
+	// Cookie the request must carry
+	// +optional
+	Cookie *CookieMatch `json:"cookie,omitempty"`
+
+	// URIPrefix the request path must start with
+	// +optional
+	URIPrefix string `json:"uriPrefix,omitempty"`
+}
+
+// HeaderMatch matches a request header. Exactly one of Exact, Prefix and Regex must be set
+type HeaderMatch struct {
+	// Name of the header
+	Name string `json:"name"`
+
+	// Exact value of the header
+	// +optional
+	Exact string `json:"exact,omitempty"`
+
+	// Prefix of the header value
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Regex the header value must match
+	// +optional
+	Regex string `json:"regex,omitempty"`
+}
+
+// CookieMatch matches a cookie of the request by its exact value
+type CookieMatch struct {
+	// Name of the cookie
+	Name string `json:"name"`
+
+	// Value of the cookie
+	Value string `json:"value"`
 }
 
 type Analysis struct {
@@ -208,6 +654,33 @@ type Analysis struct {
 
 	// List of criteria for assessing the candidate version
 	SuccessCriteria []SuccessCriterion `json:"successCriteria,omitempty"`
+
+	// FailurePolicy limits the failures of the analytics service tolerated by the experiment.
+	// Without it, failed analyses are retried until the analytics service recovers
+	// +optional
+	FailurePolicy *FailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// FailurePolicy limits the failures of the analytics service tolerated by the experiment.
+// Failed analyses are retried with exponential backoff until a limit is reached.
+type FailurePolicy struct {
+	// MaxConsecutiveFailures is the number of consecutive failed analyses after which the action is taken
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxConsecutiveFailures *int `json:"maxConsecutiveFailures,omitempty"`
+
+	// MaxOutageDuration is how long analyses may keep failing before the action is taken, e.g. "10m"
+	// +optional
+	MaxOutageDuration *string `json:"maxOutageDuration,omitempty"`
+
+	// Action is the action taken once a limit is reached. Options:
+	// "hold": keep the traffic split as is and keep retrying
+	// "rollback": roll back to the baseline and fail the experiment
+	// "increment_without_check": go on increasing the traffic without calling the analytics service.
+	// The candidates are not assessed anymore: the run only promotes one with an override_success assessment
+	// +optional. Default is "hold".
+	//+kubebuilder:validation:Enum={hold,rollback,increment_without_check}
+	Action *string `json:"action,omitempty"`
 }
 
 type Summary struct {
@@ -244,7 +717,7 @@ type SuccessCriterion struct {
 	Tolerance float64 `json:"tolerance"`
 
 	// Minimum number of data points required to make a decision based on this criterion;
-	// If not specified, the default value is 10
+	// If not specified, the cluster default (10) is used
 	// +optional
 	SampleSize *int `json:"sampleSize,omitempty"`
 
@@ -254,98 +727,207 @@ type SuccessCriterion struct {
 	StopOnFailure *bool `json:"stopOnFailure,omitempty"`
 }
 
-// GetStrategy gets the strategy used for traffic control. Default is "check_and_increment".
+// GetStrategy gets the strategy used for traffic control or the cluster default (Defaults.Strategy)
 func (t *TrafficControl) GetStrategy() string {
 	strategy := t.Strategy
 	if strategy == nil {
-		defaultValue := StrategyCheckAndIncrement
-		strategy = &defaultValue
+		strategy = &Defaults.Strategy
 	}
 	return *strategy
 }
 
-// GetMaxTrafficPercentage gets the specified max traffic percent or the default value (50)
+// GetMaxTrafficPercentage gets the specified max traffic percent, the last step of the ramp if any,
+// or the cluster default (Defaults.MaxTrafficPercentage)
 func (t *TrafficControl) GetMaxTrafficPercentage() float64 {
 	maxPercent := t.MaxTrafficPercentage
 	if maxPercent == nil {
-		fifty := float64(50)
-		maxPercent = &fifty
+		if len(t.Steps) > 0 {
+			return float64(t.Steps[len(t.Steps)-1].Percentage)
+		}
+		maxPercent = &Defaults.MaxTrafficPercentage
 	}
 	return *maxPercent
 }
 
-// GetStepSize gets the specified step size or the default value (2%)
+// GetStepSize gets the specified step size or the cluster default (Defaults.TrafficStepSize)
 func (t *TrafficControl) GetStepSize() float64 {
 	stepSize := t.TrafficStepSize
 	if stepSize == nil {
-		two := float64(2)
-		stepSize = &two
+		stepSize = &Defaults.TrafficStepSize
 	}
 	return *stepSize
 }
 
-// GetMaxIterations gets the number of iterations or the default value (100)
+// GetMaxIterations gets the number of iterations or the cluster default (Defaults.MaxIterations)
 func (t *TrafficControl) GetMaxIterations() int {
 	count := t.MaxIterations
 	if count == nil {
-		hundred := int(100)
-		count = &hundred
+		count = &Defaults.MaxIterations
 	}
 	return *count
 }
 
-// GetInterval gets the specified interval or the default value (1m)
+// GetInterval gets the specified interval or the cluster default (Defaults.Interval)
 func (t *TrafficControl) GetInterval() string {
 	interval := t.Interval
 	if interval == nil {
-		onemn := "1m"
-		interval = &onemn
+		interval = &Defaults.Interval
 	}
 	return *interval
 }
 
-// GetIntervalDuration gets the specified interval or the default value (1mn)
+// GetIntervalDuration gets the specified interval or the cluster default as a duration
 func (t *TrafficControl) GetIntervalDuration() (time.Duration, error) {
 	interval := t.GetInterval()
 
 	return time.ParseDuration(interval)
 }
 
-// GetOnSuccess describes how the traffic must be split at the end of the experiment; Default is "candidate"
+// NextStep returns the percentage of the first step of the ramp above the current candidate traffic,
+// or of the last step once it is reached. Returns false if no ramp is specified.
+func (t *TrafficControl) NextStep(current int) (int, bool) {
+	if len(t.Steps) == 0 {
+		return 0, false
+	}
+	for _, step := range t.Steps {
+		if step.Percentage > current {
+			return step.Percentage, true
+		}
+	}
+	return t.Steps[len(t.Steps)-1].Percentage, true
+}
+
+// GetDwellDuration returns the time to wait at the current candidate traffic before the next iteration:
+// the dwell of the last step of the ramp reached if specified, or else the interval
+func (t *TrafficControl) GetDwellDuration(current int) (time.Duration, error) {
+	var dwell *string
+	for _, step := range t.Steps {
+		if step.Percentage > current {
+			break
+		}
+		dwell = step.Dwell
+	}
+	if dwell == nil {
+		return t.GetIntervalDuration()
+	}
+	return time.ParseDuration(*dwell)
+}
+
+// GetPercentage gets the specified mirror percentage or 100
+func (m *MirrorPhase) GetPercentage() int {
+	if m.Percentage == nil {
+		return 100
+	}
+	return *m.Percentage
+}
+
+// GetIterations gets the specified number of mirror iterations or 1
+func (m *MirrorPhase) GetIterations() int {
+	if m.Iterations == nil {
+		return 1
+	}
+	return *m.Iterations
+}
+
+// IsMirroring tells whether the given iteration runs on mirrored traffic
+func (t *TrafficControl) IsMirroring(iteration int) bool {
+	return t.Mirror != nil && iteration < t.Mirror.GetIterations()
+}
+
+// GetOnDrift describes what happens when the routing rules are changed during the experiment; Default is "restore"
+func (t *TrafficControl) GetOnDrift() string {
+	if t.OnDrift == nil {
+		return OnDriftRestore
+	}
+	return *t.OnDrift
+}
+
+// GetAction returns the action taken once the limits of the failure policy are reached; Default is "hold"
+func (p *FailurePolicy) GetAction() string {
+	if p == nil || p.Action == nil {
+		return FailureActionHold
+	}
+	return *p.Action
+}
+
+// GetMaxOutageDuration returns the max outage duration as a time.Duration
+func (p *FailurePolicy) GetMaxOutageDuration() (time.Duration, error) {
+	return time.ParseDuration(*p.MaxOutageDuration)
+}
+
+// IsExhausted returns true if failures consecutive failed analyses over outage reach a limit of the policy.
+// Without a policy, failures are tolerated until the analytics service recovers.
+func (p *FailurePolicy) IsExhausted(failures int, outage time.Duration) bool {
+	if p == nil {
+		return false
+	}
+	if p.MaxConsecutiveFailures != nil && failures >= *p.MaxConsecutiveFailures {
+		return true
+	}
+	if p.MaxOutageDuration != nil {
+		if max, err := p.GetMaxOutageDuration(); err == nil && outage >= max {
+			return true
+		}
+	}
+	return false
+}
+
+// GetOnSuccess describes how the traffic must be split at the end of the experiment; Default is Defaults.OnSuccess
 func (t *TrafficControl) GetOnSuccess() string {
 	onsuccess := t.OnSuccess
 	if onsuccess == nil {
-		return "candidate"
+		return Defaults.OnSuccess
 	}
 	return *onsuccess
 }
 
-// GetServiceEndpoint returns the analytcis endpoint; Default is "http://iter8-analytics.iter8".
+// GetTTLAfterFinished returns the time the experiment is kept once completed; Default is Defaults.TTLSecondsAfterFinished.
+// It returns false if the experiment is kept until deleted by the user.
+func (s *ExperimentSpec) GetTTLAfterFinished() (time.Duration, bool) {
+	ttl := s.TTLSecondsAfterFinished
+	if ttl == nil {
+		ttl = Defaults.TTLSecondsAfterFinished
+	}
+	if ttl == nil {
+		return 0, false
+	}
+	return time.Duration(*ttl) * time.Second, true
+}
+
+// GetArchiveStatus returns whether the final status is archived before the experiment is deleted; Default is Defaults.ArchiveStatus.
+func (s *ExperimentSpec) GetArchiveStatus() bool {
+	archive := s.ArchiveStatus
+	if archive == nil {
+		return Defaults.ArchiveStatus
+	}
+	return *archive
+}
+
+// GetServiceEndpoint returns the analytcis endpoint; Default is Defaults.AnalyticsService.
 func (a *Analysis) GetServiceEndpoint() string {
 	endpoint := a.AnalyticsService
 	if len(endpoint) == 0 {
-		return "http://iter8-analytics.iter8"
+		return Defaults.AnalyticsService
 	}
 
 	return endpoint
 }
 
-// GetGrafanaEndpoint returns the grafana endpoint; Default is "http://localhost:3000".
+// GetGrafanaEndpoint returns the grafana endpoint; Default is Defaults.GrafanaEndpoint.
 func (a *Analysis) GetGrafanaEndpoint() string {
 	endpoint := a.GrafanaEndpoint
 	if len(endpoint) == 0 {
-		endpoint = "http://localhost:3000"
+		endpoint = Defaults.GrafanaEndpoint
 	}
 
 	return endpoint
 }
 
-// GetSampleSize returns the sample size for analytics in each iteration; Default is 10.
+// GetSampleSize returns the sample size for analytics in each iteration; Default is Defaults.SampleSize.
 func (s *SuccessCriterion) GetSampleSize() int {
 	size := s.SampleSize
 	if size == nil {
-		defaultValue := 10
-		size = &defaultValue
+		size = &Defaults.SampleSize
 	}
 	return *size
 }
@@ -381,6 +963,18 @@ const (
 
 	// ExperimentConditionRoutingRulesReady has status True when routing rules are ready
 	ExperimentConditionRoutingRulesReady duckv1alpha1.ConditionType = "RoutingRulesReady"
+
+	// ExperimentConditionPaused has status True when the experiment is paused by the user
+	ExperimentConditionPaused duckv1alpha1.ConditionType = "Paused"
+
+	// ExperimentConditionAwaitingApproval has status True when the ramp is held at an approval gate
+	ExperimentConditionAwaitingApproval duckv1alpha1.ConditionType = "AwaitingApproval"
+
+	// ExperimentConditionPolicyViolated has status True when the experiment violates an experiment policy
+	ExperimentConditionPolicyViolated duckv1alpha1.ConditionType = "PolicyViolated"
+
+	// ExperimentConditionRoutingDrifted has status True when the experiment is paused because its routing rules were changed
+	ExperimentConditionRoutingDrifted duckv1alpha1.ConditionType = "RoutingDrifted"
 )
 
 var experimentCondSet = duckv1alpha1.NewLivingConditionSet(
@@ -415,6 +1009,12 @@ func (s *ExperimentStatus) MarkMetricsSyncedError(reason, messageFormat string,
 	s.Message = composeMessage(reason, messageFormat, messageA...)
 }
 
+// MarkTemplateError records that the template referenced by the experiment cannot be merged
+func (s *ExperimentStatus) MarkTemplateError(reason, messageFormat string, messageA ...interface{}) {
+	s.Phase = PhasePause
+	s.Message = composeMessage(reason, messageFormat, messageA...)
+}
+
 // MarkTargetsFound sets the condition that the all target have been found
 // Return true if it's converted from false or unknown
 func (s *ExperimentStatus) MarkTargetsFound() bool {
@@ -447,17 +1047,29 @@ func (s *ExperimentStatus) MarkRoutingRulesError(reason, messageFormat string, m
 
 // MarkAnalyticsServiceRunning sets the condition that the analytics service is operating normally
 // Return true if it's converted from false or unknown
+// The count of consecutive failed analyses is reset, as well as the hold of the failure policy.
 func (s *ExperimentStatus) MarkAnalyticsServiceRunning() bool {
 	prev := s.GetCondition(ExperimentConditionAnalyticsServiceNormal).Status
 	experimentCondSet.Manage(s).MarkTrue(ExperimentConditionAnalyticsServiceNormal)
+	s.AnalyticsFailures = 0
+	s.AnalyticsFailingSince = nil
+	if s.FailurePolicyAction == FailureActionHold {
+		s.FailurePolicyAction = ""
+	}
 	return prev != corev1.ConditionTrue
 }
 
 // MarkAnalyticsServiceError sets the condition that the analytics service has breakdown
+// and counts one more consecutive failed analysis
 func (s *ExperimentStatus) MarkAnalyticsServiceError(reason, messageFormat string, messageA ...interface{}) {
 	experimentCondSet.Manage(s).MarkFalse(ExperimentConditionAnalyticsServiceNormal, reason, messageFormat, messageA...)
 	s.Message = composeMessage(reason, messageFormat, messageA...)
 	s.Phase = PhasePause
+	if s.AnalyticsFailingSince == nil {
+		now := metav1.Now()
+		s.AnalyticsFailingSince = &now
+	}
+	s.AnalyticsFailures++
 }
 
 // MarkExperimentCompleted sets the condition that the experiemnt is completed
@@ -487,6 +1099,106 @@ func (s *ExperimentStatus) MarkExperimentFailed(reason, messageFormat string, me
 	s.Message = composeMessage(reason, messageFormat, messageA...)
 }
 
+// MarkExperimentPaused sets the condition that the experiment is paused by the user
+func (s *ExperimentStatus) MarkExperimentPaused(reason, messageFormat string, messageA ...interface{}) {
+	experimentCondSet.Manage(s).MarkTrue(ExperimentConditionPaused)
+	s.Phase = PhasePause
+	s.Message = composeMessage(reason, messageFormat, messageA...)
+}
+
+// MarkExperimentResumed sets the condition that the experiment is no longer paused by the user
+func (s *ExperimentStatus) MarkExperimentResumed(reason, messageFormat string, messageA ...interface{}) {
+	experimentCondSet.Manage(s).MarkFalse(ExperimentConditionPaused, reason, messageFormat, messageA...)
+	s.Phase = PhaseProgressing
+	s.Message = composeMessage(reason, messageFormat, messageA...)
+}
+
+// MarkAwaitingApproval sets the condition that the ramp is held at an approval gate
+func (s *ExperimentStatus) MarkAwaitingApproval(reason, messageFormat string, messageA ...interface{}) {
+	experimentCondSet.Manage(s).MarkTrue(ExperimentConditionAwaitingApproval)
+	s.Phase = PhasePause
+	s.Message = composeMessage(reason, messageFormat, messageA...)
+}
+
+// MarkApproved sets the condition that the ramp is no longer held at an approval gate
+func (s *ExperimentStatus) MarkApproved(reason, messageFormat string, messageA ...interface{}) {
+	experimentCondSet.Manage(s).MarkFalse(ExperimentConditionAwaitingApproval, reason, messageFormat, messageA...)
+	s.Phase = PhaseProgressing
+	s.Message = composeMessage(reason, messageFormat, messageA...)
+}
+
+// IsAwaitingApproval tells whether the ramp is held at an approval gate
+func (s *ExperimentStatus) IsAwaitingApproval() bool {
+	awaiting := s.GetCondition(ExperimentConditionAwaitingApproval)
+	return awaiting != nil && awaiting.Status == corev1.ConditionTrue
+}
+
+// IsApproved tells whether the approval gate has been approved
+func (s *ExperimentStatus) IsApproved(gate int) bool {
+	for _, approval := range s.Approvals {
+		if approval.Gate == gate {
+			return true
+		}
+	}
+	return false
+}
+
+// PendingGate returns the lowest approval gate not approved yet, if any
+func (e *Experiment) PendingGate() (int, bool) {
+	for _, gate := range e.Spec.TrafficControl.ApprovalGates {
+		if !e.Status.IsApproved(gate) {
+			return gate, true
+		}
+	}
+	return 0, false
+}
+
+// MarkPolicyViolated sets the condition that the experiment violates an experiment policy
+func (s *ExperimentStatus) MarkPolicyViolated(reason, messageFormat string, messageA ...interface{}) {
+	experimentCondSet.Manage(s).MarkTrue(ExperimentConditionPolicyViolated)
+	s.Phase = PhasePause
+	s.Message = composeMessage(reason, messageFormat, messageA...)
+}
+
+// MarkPolicyCompliant sets the condition that the experiment no longer violates any experiment policy
+func (s *ExperimentStatus) MarkPolicyCompliant(reason, messageFormat string, messageA ...interface{}) {
+	experimentCondSet.Manage(s).MarkFalse(ExperimentConditionPolicyViolated, reason, messageFormat, messageA...)
+	s.Phase = PhaseProgressing
+	s.Message = composeMessage(reason, messageFormat, messageA...)
+}
+
+// MarkRoutingDrifted sets the condition that the experiment is paused because its routing rules were changed
+func (s *ExperimentStatus) MarkRoutingDrifted(reason, messageFormat string, messageA ...interface{}) {
+	experimentCondSet.Manage(s).MarkTrue(ExperimentConditionRoutingDrifted)
+	s.Phase = PhasePause
+	s.Message = composeMessage(reason, messageFormat, messageA...)
+}
+
+// MarkRoutingRestored sets the condition that the routing rules match the traffic split of the experiment again
+func (s *ExperimentStatus) MarkRoutingRestored(reason, messageFormat string, messageA ...interface{}) {
+	experimentCondSet.Manage(s).MarkFalse(ExperimentConditionRoutingDrifted, reason, messageFormat, messageA...)
+	s.Phase = PhaseProgressing
+	s.Message = composeMessage(reason, messageFormat, messageA...)
+}
+
+// IsRoutingDrifted tells whether the experiment is paused because its routing rules were changed
+func (s *ExperimentStatus) IsRoutingDrifted() bool {
+	drifted := s.GetCondition(ExperimentConditionRoutingDrifted)
+	return drifted != nil && drifted.Status == corev1.ConditionTrue
+}
+
+// IsPolicyViolated tells whether the experiment violates an experiment policy
+func (s *ExperimentStatus) IsPolicyViolated() bool {
+	violated := s.GetCondition(ExperimentConditionPolicyViolated)
+	return violated != nil && violated.Status == corev1.ConditionTrue
+}
+
+// IsPaused tells whether the experiment is paused by the user
+func (s *ExperimentStatus) IsPaused() bool {
+	paused := s.GetCondition(ExperimentConditionPaused)
+	return paused != nil && paused.Status == corev1.ConditionTrue
+}
+
 func composeMessage(reason, messageFormat string, messageA ...interface{}) string {
 	out := reason
 	if len(fmt.Sprintf(messageFormat, messageA...)) > 0 {
@@ -0,0 +1,102 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+)
+
+func TestRecordIteration(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &ExperimentStatus{}
+	for i := 1; i <= MaxIterationHistory+10; i++ {
+		status.RecordIteration(IterationRecord{Iteration: i})
+	}
+	g.Expect(status.History).To(gomega.HaveLen(MaxIterationHistory))
+	g.Expect(status.History[0].Iteration).To(gomega.Equal(1))
+	g.Expect(status.History[1].Iteration).To(gomega.Equal(12))
+	g.Expect(status.History[MaxIterationHistory-1].Iteration).To(gomega.Equal(MaxIterationHistory + 10))
+	g.Expect(status.CompactedIterations).To(gomega.Equal(10))
+
+	status = &ExperimentStatus{}
+	conclusion := strings.Repeat("x", MaxIterationHistoryBytes/10)
+	for i := 1; i <= 20; i++ {
+		status.RecordIteration(IterationRecord{Iteration: i, Conclusions: []string{conclusion}, Error: conclusion})
+	}
+	g.Expect(historySize(status.History)).To(gomega.BeNumerically("<=", MaxIterationHistoryBytes))
+	g.Expect(status.History[0].Iteration).To(gomega.Equal(1))
+	g.Expect(status.History[len(status.History)-1].Iteration).To(gomega.Equal(20))
+	g.Expect(status.History[0].Error).To(gomega.HaveLen(maxRecordErrorLength))
+	g.Expect(status.CompactedIterations).To(gomega.Equal(20 - len(status.History)))
+}
+
+func TestTrafficSteps(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	interval, dwell := "1m", "10m"
+	traffic := &TrafficControl{
+		Interval: &interval,
+		Steps:    []TrafficStep{{Percentage: 1}, {Percentage: 5, Dwell: &dwell}, {Percentage: 50}},
+	}
+	g.Expect(traffic.GetMaxTrafficPercentage()).To(gomega.Equal(float64(50)))
+	next, ok := traffic.NextStep(5)
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(next).To(gomega.Equal(50))
+	g.Expect(traffic.GetDwellDuration(0)).To(gomega.Equal(time.Minute))
+	g.Expect(traffic.GetDwellDuration(7)).To(gomega.Equal(10 * time.Minute))
+	g.Expect(traffic.GetDwellDuration(50)).To(gomega.Equal(time.Minute))
+
+	_, ok = (&TrafficControl{}).NextStep(0)
+	g.Expect(ok).To(gomega.BeFalse())
+}
+
+func TestRerun(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &ExperimentStatus{}
+	status.ObservedGeneration = 3
+	for i := 1; i <= MaxPreviousRuns+2; i++ {
+		status.InitializeConditions()
+		status.StartTimestamp = "1000"
+		status.EndTimestamp = "2000"
+		status.CurrentIteration = i
+		status.TrafficSplit = TrafficSplit{Baseline: 0, Candidate: 100}
+		status.Winner = "reviews-v2"
+		status.History = []IterationRecord{{Iteration: i}}
+		status.Rerun(strconv.Itoa(i))
+	}
+	g.Expect(status.PreviousRuns).To(gomega.HaveLen(MaxPreviousRuns))
+	g.Expect(status.PreviousRuns[0].Iterations).To(gomega.Equal(3))
+	g.Expect(status.PreviousRuns[MaxPreviousRuns-1]).To(gomega.Equal(RunSummary{
+		StartTimestamp: "1000",
+		EndTimestamp:   "2000",
+		Iterations:     MaxPreviousRuns + 2,
+		TrafficSplit:   TrafficSplit{Baseline: 0, Candidate: 100},
+		Winner:         "reviews-v2",
+	}))
+	g.Expect(status.LastRerun).To(gomega.Equal(strconv.Itoa(MaxPreviousRuns + 2)))
+	g.Expect(status.ObservedGeneration).To(gomega.Equal(int64(3)))
+	g.Expect(status.CurrentIteration).To(gomega.BeZero())
+	g.Expect(status.StartTimestamp).To(gomega.BeEmpty())
+	g.Expect(status.Conditions).To(gomega.BeEmpty())
+	g.Expect(status.History).To(gomega.BeEmpty())
+}
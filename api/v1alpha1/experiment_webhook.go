@@ -0,0 +1,566 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const (
+	// metricsConfigMap and iter8Namespace locate the metric definitions read by the controller
+	metricsConfigMap = "iter8-metrics"
+	iter8Namespace   = "iter8"
+)
+
+// log is for logging in this package.
+var experimentlog = logf.Log.WithName("experiment-resource")
+
+// webhookReader reads the cluster state needed to validate experiments
+var webhookReader client.Reader
+
+func (r *Experiment) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-iter8-iter8-tools-v1alpha1-experiment,mutating=true,failurePolicy=fail,groups=iter8.iter8.tools,resources=experiments,verbs=create;update,versions=v1alpha1,name=mexperiment.kb.io
+
+var _ webhook.Defaulter = &Experiment{}
+
+// DefaultedAnnotation lists the spec fields filled in by the defaulting webhook, separated by commas.
+// The template referenced by the experiment overrides them
+const DefaultedAnnotation = "iter8.tools/defaulted"
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type.
+// It writes the effective value of every unset TrafficControl and Analysis field into the spec.
+// When the experiment references a template, the filled fields are recorded so that the template still applies to them.
+func (r *Experiment) Default() {
+	experimentlog.Info("default", "name", r.Name)
+
+	defaulted := r.Spec.ApplyDefaults()
+	if r.Spec.Template == "" || len(defaulted) == 0 {
+		return
+	}
+
+	fields := r.defaultedFields()
+	for _, name := range defaulted {
+		fields[name] = true
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	annotations := r.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[DefaultedAnnotation] = strings.Join(names, ",")
+	r.SetAnnotations(annotations)
+}
+
+// defaultedFields returns the spec fields recorded as filled in by the defaulting webhook
+func (r *Experiment) defaultedFields() map[string]bool {
+	fields := map[string]bool{}
+	if value := r.GetAnnotations()[DefaultedAnnotation]; value != "" {
+		for _, name := range strings.Split(value, ",") {
+			fields[name] = true
+		}
+	}
+	return fields
+}
+
+// ApplyDefaults writes the effective value of every unset TrafficControl and Analysis field into the spec.
+// It returns the fields it filled in, but for those of the success criteria
+func (s *ExperimentSpec) ApplyDefaults() []string {
+	defaulted := []string{}
+
+	a := &s.Analysis
+	for i := range a.SuccessCriteria {
+		criterion := &a.SuccessCriteria[i]
+		if criterion.SampleSize == nil {
+			size := criterion.GetSampleSize()
+			criterion.SampleSize = &size
+		}
+		if criterion.StopOnFailure == nil {
+			stop := criterion.GetStopOnFailure()
+			criterion.StopOnFailure = &stop
+		}
+	}
+
+	t := &s.TrafficControl
+	if t.Strategy == nil {
+		strategy := t.GetStrategy()
+		// without success criteria there is nothing to assess
+		if len(s.Analysis.SuccessCriteria) == 0 {
+			strategy = StrategyIncrementWithoutCheck
+		}
+		t.Strategy = &strategy
+		defaulted = append(defaulted, "trafficControl.strategy")
+	}
+	if t.MaxTrafficPercentage == nil {
+		maxPercent := t.GetMaxTrafficPercentage()
+		t.MaxTrafficPercentage = &maxPercent
+		defaulted = append(defaulted, "trafficControl.maxTrafficPercentage")
+	}
+	if t.TrafficStepSize == nil {
+		stepSize := t.GetStepSize()
+		t.TrafficStepSize = &stepSize
+		defaulted = append(defaulted, "trafficControl.trafficStepSize")
+	}
+	if t.Interval == nil {
+		interval := t.GetInterval()
+		t.Interval = &interval
+		defaulted = append(defaulted, "trafficControl.interval")
+	}
+	if t.MaxIterations == nil {
+		count := t.GetMaxIterations()
+		t.MaxIterations = &count
+		defaulted = append(defaulted, "trafficControl.maxIterations")
+	}
+	if t.OnSuccess == nil {
+		onSuccess := t.GetOnSuccess()
+		t.OnSuccess = &onSuccess
+		defaulted = append(defaulted, "trafficControl.onSuccess")
+	}
+
+	if a.AnalyticsService == "" {
+		defaulted = append(defaulted, "analysis.analyticsService")
+	}
+	if a.GrafanaEndpoint == "" {
+		defaulted = append(defaulted, "analysis.grafanaEndpoint")
+	}
+	a.AnalyticsService = a.GetServiceEndpoint()
+	a.GrafanaEndpoint = a.GetGrafanaEndpoint()
+	return defaulted
+}
+
+// unsetDefaulted clears the given spec fields, as named by ApplyDefaults
+func (s *ExperimentSpec) unsetDefaulted(fields map[string]bool) {
+	t, a := &s.TrafficControl, &s.Analysis
+	for name := range fields {
+		switch name {
+		case "trafficControl.strategy":
+			t.Strategy = nil
+		case "trafficControl.maxTrafficPercentage":
+			t.MaxTrafficPercentage = nil
+		case "trafficControl.trafficStepSize":
+			t.TrafficStepSize = nil
+		case "trafficControl.interval":
+			t.Interval = nil
+		case "trafficControl.maxIterations":
+			t.MaxIterations = nil
+		case "trafficControl.onSuccess":
+			t.OnSuccess = nil
+		case "analysis.analyticsService":
+			a.AnalyticsService = ""
+		case "analysis.grafanaEndpoint":
+			a.GrafanaEndpoint = ""
+		}
+	}
+}
+
+// +kubebuilder:webhook:verbs=create;update,path=/validate-iter8-iter8-tools-v1alpha1-experiment,mutating=false,failurePolicy=fail,groups=iter8.iter8.tools,resources=experiments,versions=v1alpha1,name=vexperiment.kb.io
+
+var _ webhook.Validator = &Experiment{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Experiment) ValidateCreate() error {
+	experimentlog.Info("validate create", "name", r.Name)
+
+	allErrs := r.validateSpec()
+	allErrs = append(allErrs, r.validatePolicies()...)
+	return r.toInvalid(allErrs)
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type.
+// The spec is validated only when it changes, so that the controller can always update the status and
+// the finalizers, and objects being deleted are not validated at all
+func (r *Experiment) ValidateUpdate(old runtime.Object) error {
+	experimentlog.Info("validate update", "name", r.Name)
+
+	oldExperiment, ok := old.(*Experiment)
+	if !ok || r.DeletionTimestamp != nil || equality.Semantic.DeepEqual(oldExperiment.Spec, r.Spec) {
+		return nil
+	}
+
+	allErrs := r.validateSpec()
+	if !equality.Semantic.DeepEqual(oldExperiment.Spec.TrafficControl, r.Spec.TrafficControl) ||
+		!equality.Semantic.DeepEqual(oldExperiment.Spec.Analysis, r.Spec.Analysis) {
+		allErrs = append(allErrs, r.validatePolicies()...)
+	}
+
+	running := oldExperiment.Status.StartTimestamp != "" && oldExperiment.Status.Phase != PhaseCompleted
+	if running && !equality.Semantic.DeepEqual(oldExperiment.Spec.TargetService, r.Spec.TargetService) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "targetService"),
+			"may not be changed while the experiment is running"))
+	}
+	if running && oldExperiment.Spec.DryRun != r.Spec.DryRun {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "dryRun"),
+			"may not be changed while the experiment is running"))
+	}
+	if running && oldExperiment.Spec.RoutingProvider != r.Spec.RoutingProvider {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "routingProvider"),
+			"may not be changed while the experiment is running"))
+	}
+
+	return r.toInvalid(allErrs)
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Experiment) ValidateDelete() error {
+	return nil
+}
+
+func (r *Experiment) toInvalid(allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("Experiment").GroupKind(), r.Name, allErrs)
+}
+
+func (r *Experiment) validateSpec() field.ErrorList {
+	specPath := field.NewPath("spec")
+
+	allErrs := validateTargetService(&r.Spec.TargetService, specPath.Child("targetService"))
+	allErrs = append(allErrs, validateTrafficControl(&r.Spec.TrafficControl, specPath.Child("trafficControl"))...)
+	allErrs = append(allErrs, r.validateSuccessCriteria(specPath.Child("analysis", "successCriteria"))...)
+	allErrs = append(allErrs, validateFailurePolicy(r.Spec.Analysis.FailurePolicy, specPath.Child("analysis", "failurePolicy"))...)
+	allErrs = append(allErrs, r.validateMatchRules(specPath.Child("trafficControl", "match"))...)
+	allErrs = append(allErrs, r.validateMirror(specPath.Child("trafficControl", "mirror"))...)
+	allErrs = append(allErrs, r.validateRoutingReference(specPath.Child("routingReference"))...)
+	return allErrs
+}
+
+// ValidateTemplated checks the settings of the spec once merged with its template, which the admission webhook
+// has not seen. The metric names of the success criteria are left to the analytics service
+func (r *Experiment) ValidateTemplated() error {
+	specPath := field.NewPath("spec")
+
+	allErrs := validateTrafficControl(&r.Spec.TrafficControl, specPath.Child("trafficControl"))
+	allErrs = append(allErrs, validateFailurePolicy(r.Spec.Analysis.FailurePolicy, specPath.Child("analysis", "failurePolicy"))...)
+	allErrs = append(allErrs, r.validateMatchRules(specPath.Child("trafficControl", "match"))...)
+	allErrs = append(allErrs, r.validateMirror(specPath.Child("trafficControl", "mirror"))...)
+	return r.toInvalid(allErrs)
+}
+
+func validateTargetService(t *TargetService, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if t.ObjectReference == nil || t.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("name"), "must specify the target service"))
+	}
+	if t.Baseline == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("baseline"), "must specify the baseline"))
+	}
+	if t.Candidate == "" && len(t.Candidates) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("candidate"), "must specify at least one candidate"))
+	}
+
+	seen := map[string]bool{}
+	for i, candidate := range t.Candidates {
+		switch {
+		case candidate == "":
+			allErrs = append(allErrs, field.Required(fldPath.Child("candidates").Index(i), "must not be empty"))
+		case candidate == t.Baseline:
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("candidates").Index(i), candidate, "must differ from the baseline"))
+		case seen[candidate]:
+			allErrs = append(allErrs, field.Duplicate(fldPath.Child("candidates").Index(i), candidate))
+		}
+		seen[candidate] = true
+	}
+	if t.Candidate != "" && t.Candidate == t.Baseline {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("candidate"), t.Candidate, "must differ from the baseline"))
+	}
+
+	return allErrs
+}
+
+func validateFailurePolicy(p *FailurePolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if p == nil {
+		return allErrs
+	}
+
+	if p.MaxConsecutiveFailures != nil && *p.MaxConsecutiveFailures <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxConsecutiveFailures"), *p.MaxConsecutiveFailures,
+			"must be greater than 0"))
+	}
+	if p.MaxOutageDuration != nil {
+		if outage, err := p.GetMaxOutageDuration(); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("maxOutageDuration"), *p.MaxOutageDuration, err.Error()))
+		} else if outage <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("maxOutageDuration"), *p.MaxOutageDuration,
+				"must be a positive duration"))
+		}
+	}
+	return allErrs
+}
+
+func validateTrafficControl(t *TrafficControl, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if t.MaxTrafficPercentage != nil && (*t.MaxTrafficPercentage <= 0 || *t.MaxTrafficPercentage > 100) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxTrafficPercentage"), *t.MaxTrafficPercentage,
+			"must be greater than 0 and at most 100"))
+	}
+	if t.TrafficStepSize != nil && *t.TrafficStepSize <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("trafficStepSize"), *t.TrafficStepSize, "must be greater than 0"))
+	}
+	if t.Interval != nil {
+		if interval, err := t.GetIntervalDuration(); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("interval"), *t.Interval, err.Error()))
+		} else if interval <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("interval"), *t.Interval, "must be a positive duration"))
+		}
+	}
+	if t.MaxIterations != nil && *t.MaxIterations <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxIterations"), *t.MaxIterations, "must be greater than 0"))
+	}
+	previous := 0
+	for i, step := range t.Steps {
+		stepPath := fldPath.Child("steps").Index(i)
+		if step.Percentage <= previous || step.Percentage > 100 {
+			allErrs = append(allErrs, field.Invalid(stepPath.Child("percentage"), step.Percentage,
+				"must be greater than the previous step and at most 100"))
+		}
+		previous = step.Percentage
+		if step.Dwell != nil {
+			if dwell, err := time.ParseDuration(*step.Dwell); err != nil {
+				allErrs = append(allErrs, field.Invalid(stepPath.Child("dwell"), *step.Dwell, err.Error()))
+			} else if dwell <= 0 {
+				allErrs = append(allErrs, field.Invalid(stepPath.Child("dwell"), *step.Dwell, "must be a positive duration"))
+			}
+		}
+	}
+	previous = 0
+	for i, gate := range t.ApprovalGates {
+		if gate <= previous || gate >= 100 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("approvalGates").Index(i), gate,
+				"must be greater than the previous gate and less than 100"))
+		}
+		previous = gate
+	}
+	if n := len(t.Steps); n > 0 && t.MaxTrafficPercentage != nil && float64(t.Steps[n-1].Percentage) > *t.MaxTrafficPercentage {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("steps").Index(n-1).Child("percentage"), t.Steps[n-1].Percentage,
+			"must not exceed maxTrafficPercentage"))
+	}
+
+	return allErrs
+}
+
+func (r *Experiment) validateSuccessCriteria(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if len(r.Spec.Analysis.SuccessCriteria) == 0 {
+		return allErrs
+	}
+
+	metrics, err := r.readMetricNames()
+	if err != nil {
+		return append(allErrs, field.InternalError(fldPath, err))
+	}
+
+	for i, criterion := range r.Spec.Analysis.SuccessCriteria {
+		if metrics != nil && !metrics[criterion.MetricName] {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Index(i).Child("metricName"), criterion.MetricName, metricNames(metrics)))
+		}
+		if criterion.SampleSize != nil && *criterion.SampleSize <= 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Index(i).Child("sampleSize"), *criterion.SampleSize, "must be greater than 0"))
+		}
+	}
+
+	return allErrs
+}
+
+func (r *Experiment) validateMatchRules(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	rules := r.Spec.TrafficControl.Match
+	if len(rules) == 0 {
+		return allErrs
+	}
+
+	if !r.isIstioRouting() {
+		return append(allErrs, field.Forbidden(fldPath, "match rules are only supported for Istio routing"))
+	}
+
+	candidates := map[string]bool{}
+	for _, candidate := range r.Spec.TargetService.GetCandidates() {
+		candidates[candidate] = true
+	}
+	for i, rule := range rules {
+		rulePath := fldPath.Index(i)
+		if rule.Candidate != "" && !candidates[rule.Candidate] {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("candidate"), rule.Candidate, "must be one of the candidates"))
+		}
+		if len(rule.Headers) == 0 && rule.Cookie == nil && rule.URIPrefix == "" {
+			allErrs = append(allErrs, field.Required(rulePath, "must specify at least one of headers, cookie or uriPrefix"))
+		}
+		for j, header := range rule.Headers {
+			headerPath := rulePath.Child("headers").Index(j)
+			if header.Name == "" {
+				allErrs = append(allErrs, field.Required(headerPath.Child("name"), "must specify the header name"))
+			}
+			set := 0
+			for _, value := range []string{header.Exact, header.Prefix, header.Regex} {
+				if value != "" {
+					set++
+				}
+			}
+			if set != 1 {
+				allErrs = append(allErrs, field.Invalid(headerPath, header.Name, "must specify exactly one of exact, prefix or regex"))
+			}
+		}
+		if rule.Cookie != nil && (rule.Cookie.Name == "" || rule.Cookie.Value == "") {
+			allErrs = append(allErrs, field.Required(rulePath.Child("cookie"), "must specify the cookie name and value"))
+		}
+	}
+
+	return allErrs
+}
+
+func (r *Experiment) validateMirror(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	mirror := r.Spec.TrafficControl.Mirror
+	if mirror == nil {
+		return allErrs
+	}
+
+	if !r.isIstioRouting() {
+		return append(allErrs, field.Forbidden(fldPath, "mirroring is only supported for Istio routing"))
+	}
+	if len(r.Spec.TargetService.GetCandidates()) > 1 {
+		allErrs = append(allErrs, field.Forbidden(fldPath, "mirroring is only supported for a single candidate"))
+	}
+	if mirror.Percentage != nil && (*mirror.Percentage <= 0 || *mirror.Percentage > 100) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("percentage"), *mirror.Percentage,
+			"must be greater than 0 and at most 100"))
+	}
+	if mirror.Iterations != nil && *mirror.Iterations <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("iterations"), *mirror.Iterations, "must be greater than 0"))
+	} else if max := r.Spec.TrafficControl.MaxIterations; max != nil && mirror.GetIterations() >= *max {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("iterations"), mirror.GetIterations(),
+			"must be less than maxIterations"))
+	}
+
+	return allErrs
+}
+
+func (r *Experiment) validateRoutingReference(fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if r.Spec.RoutingProvider != RoutingProviderGateway {
+		return allErrs
+	}
+
+	ref := r.Spec.RoutingReference
+	if ref == nil || ref.Name == "" {
+		return append(allErrs, field.Required(fldPath.Child("name"), "must name the HTTPRoute driven by the gateway routing provider"))
+	}
+	if ref.Kind != "" && ref.Kind != "HTTPRoute" {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("kind"), ref.Kind, "must be HTTPRoute"))
+	}
+	return allErrs
+}
+
+// isKnativeTarget tells whether the target service is a Knative service
+func (r *Experiment) isKnativeTarget() bool {
+	t := r.Spec.TargetService.ObjectReference
+	return t != nil && strings.HasPrefix(t.APIVersion, "serving.knative.dev/")
+}
+
+// isIstioRouting tells whether the traffic of the target service is routed by Istio
+func (r *Experiment) isIstioRouting() bool {
+	provider := r.Spec.RoutingProvider
+	return !r.isKnativeTarget() && (provider == "" || provider == RoutingProviderIstio)
+}
+
+// validatePolicies checks the experiment against the experiment policies of its namespace.
+// Experiments referencing a template are checked by the controller once the template is merged.
+func (r *Experiment) validatePolicies() field.ErrorList {
+	if webhookReader == nil || r.Spec.Template != "" {
+		return nil
+	}
+
+	policies := &ExperimentPolicyList{}
+	if err := webhookReader.List(context.Background(), policies); err != nil {
+		return field.ErrorList{field.InternalError(field.NewPath("spec"), err)}
+	}
+	allErrs := field.ErrorList{}
+	for i := range policies.Items {
+		if policy := &policies.Items[i]; policy.AppliesTo(r.Namespace) {
+			allErrs = append(allErrs, policy.Violations(r)...)
+		}
+	}
+	return allErrs
+}
+
+// readMetricNames returns the names of the metrics defined in the iter8-metrics config map,
+// looked up in the iter8 namespace first and then in the namespace of the experiment.
+// Returns nil if the cluster cannot be read or has no such config map, in which case metric names are not checked
+func (r *Experiment) readMetricNames() (map[string]bool, error) {
+	if webhookReader == nil {
+		return nil, nil
+	}
+
+	ctx := context.Background()
+	cm := &corev1.ConfigMap{}
+	if err := webhookReader.Get(ctx, types.NamespacedName{Name: metricsConfigMap, Namespace: iter8Namespace}, cm); err != nil {
+		if err = webhookReader.Get(ctx, types.NamespacedName{Name: metricsConfigMap, Namespace: r.Namespace}, cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	metrics := []struct {
+		Name string `yaml:"name"`
+	}{}
+	if err := yaml.Unmarshal([]byte(cm.Data["metrics"]), &metrics); err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, metric := range metrics {
+		names[metric.Name] = true
+	}
+	return names, nil
+}
+
+func metricNames(metrics map[string]bool) []string {
+	out := make([]string, 0, len(metrics))
+	for name := range metrics {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
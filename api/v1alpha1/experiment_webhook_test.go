@@ -0,0 +1,189 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestExperiment() *Experiment {
+	return &Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2-rollout", Namespace: "bookinfo"},
+		Spec: ExperimentSpec{
+			TargetService: TargetService{
+				ObjectReference: &corev1.ObjectReference{APIVersion: "v1", Name: "reviews"},
+				Baseline:        "reviews-v1",
+				Candidate:       "reviews-v2",
+			},
+		},
+	}
+}
+
+func TestValidateCreate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(newTestExperiment().ValidateCreate()).To(gomega.Succeed())
+
+	exp := newTestExperiment()
+	exp.Spec.TargetService.Candidate = ""
+	g.Expect(exp.ValidateCreate()).To(gomega.MatchError(gomega.ContainSubstring("spec.targetService.candidate")))
+
+	exp = newTestExperiment()
+	exp.Spec.TargetService.Candidates = []string{"reviews-v3", "reviews-v3"}
+	g.Expect(exp.ValidateCreate()).To(gomega.MatchError(gomega.ContainSubstring("spec.targetService.candidates[1]")))
+
+	exp = newTestExperiment()
+	maxPercent, stepSize, interval := float64(120), float64(-2), "1 minute"
+	exp.Spec.TrafficControl = TrafficControl{
+		MaxTrafficPercentage: &maxPercent,
+		TrafficStepSize:      &stepSize,
+		Interval:             &interval,
+	}
+	err := exp.ValidateCreate()
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.maxTrafficPercentage")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.trafficStepSize")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.interval")))
+
+	exp = newTestExperiment()
+	dwell := "0s"
+	exp.Spec.TrafficControl = TrafficControl{
+		Steps: []TrafficStep{{Percentage: 10}, {Percentage: 5, Dwell: &dwell}},
+	}
+	err = exp.ValidateCreate()
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.steps[1].percentage")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.steps[1].dwell")))
+
+	exp = newTestExperiment()
+	exp.Spec.TrafficControl.Match = []MatchRule{
+		{Candidate: "reviews-v9", Headers: []HeaderMatch{{Name: "x-user", Exact: "jason", Prefix: "j"}}},
+		{},
+	}
+	err = exp.ValidateCreate()
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.match[0].candidate")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.match[0].headers[0]")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.match[1]")))
+
+	exp = newTestExperiment()
+	exp.Spec.RoutingProvider = RoutingProviderSMI
+	exp.Spec.TrafficControl.Match = []MatchRule{{URIPrefix: "/v2"}}
+	g.Expect(exp.ValidateCreate()).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.match")))
+	exp.Spec.RoutingProvider = RoutingProviderIstio
+	g.Expect(exp.ValidateCreate()).To(gomega.Succeed())
+
+	exp = newTestExperiment()
+	exp.Spec.RoutingProvider = RoutingProviderGateway
+	g.Expect(exp.ValidateCreate()).To(gomega.MatchError(gomega.ContainSubstring("spec.routingReference.name")))
+	exp.Spec.RoutingReference = &corev1.ObjectReference{Kind: "VirtualService", Name: "reviews"}
+	g.Expect(exp.ValidateCreate()).To(gomega.MatchError(gomega.ContainSubstring("spec.routingReference.kind")))
+	exp.Spec.RoutingReference.Kind = "HTTPRoute"
+	g.Expect(exp.ValidateCreate()).To(gomega.Succeed())
+}
+
+func TestValidateUpdate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	old := newTestExperiment()
+	exp := newTestExperiment()
+	exp.Spec.TargetService.Candidate = "reviews-v3"
+	g.Expect(exp.ValidateUpdate(old)).To(gomega.Succeed())
+
+	old.Status.StartTimestamp = "1600000000000"
+	old.Status.Phase = PhaseProgressing
+	g.Expect(exp.ValidateUpdate(old)).To(gomega.MatchError(gomega.ContainSubstring("spec.targetService")))
+	old.Status.Phase = PhasePause
+	g.Expect(exp.ValidateUpdate(old)).To(gomega.MatchError(gomega.ContainSubstring("spec.targetService")))
+
+	exp = newTestExperiment()
+	exp.Spec.DryRun = true
+	g.Expect(exp.ValidateUpdate(old)).To(gomega.MatchError(gomega.ContainSubstring("spec.dryRun")))
+	old.Status.Phase = PhaseCompleted
+	g.Expect(exp.ValidateUpdate(old)).To(gomega.Succeed())
+
+	exp = newTestExperiment()
+	exp.Spec.RoutingProvider = RoutingProviderSMI
+	old.Status.Phase = PhaseProgressing
+	g.Expect(exp.ValidateUpdate(old)).To(gomega.MatchError(gomega.ContainSubstring("spec.routingProvider")))
+
+	// only a changed spec is validated, and experiments being deleted are not validated at all
+	old = newTestExperiment()
+	old.Spec.TargetService.Candidate = ""
+	old.Finalizers = []string{"finalizer.iter8-tools"}
+	exp = old.DeepCopy()
+	exp.Finalizers = nil
+	g.Expect(exp.ValidateUpdate(old)).To(gomega.Succeed())
+	exp.Spec.TargetService.Baseline = ""
+	g.Expect(exp.ValidateUpdate(old)).To(gomega.MatchError(gomega.ContainSubstring("spec.targetService.candidate")))
+	now := metav1.Now()
+	exp.DeletionTimestamp = &now
+	g.Expect(exp.ValidateUpdate(old)).To(gomega.Succeed())
+}
+
+func TestValidateMetricNames(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(AddToScheme(s)).To(gomega.Succeed())
+	defer func() { webhookReader = nil }()
+
+	exp := newTestExperiment()
+	exp.Spec.Analysis.SuccessCriteria = []SuccessCriterion{{MetricName: "iter8_error_rate", ToleranceType: ToleranceTypeThreshold}}
+
+	// metric names are unchecked without the iter8-metrics config map
+	webhookReader = fake.NewFakeClientWithScheme(s)
+	g.Expect(exp.ValidateCreate()).To(gomega.Succeed())
+
+	webhookReader = fake.NewFakeClientWithScheme(s, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: metricsConfigMap, Namespace: iter8Namespace},
+		Data:       map[string]string{"metrics": "- name: iter8_latency\n"},
+	})
+	g.Expect(exp.ValidateCreate()).To(gomega.MatchError(gomega.ContainSubstring("spec.analysis.successCriteria[0].metricName")))
+}
+
+func TestDefault(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	exp := newTestExperiment()
+	exp.Default()
+	g.Expect(*exp.Spec.TrafficControl.Strategy).To(gomega.Equal(StrategyIncrementWithoutCheck))
+	g.Expect(*exp.Spec.TrafficControl.MaxTrafficPercentage).To(gomega.Equal(float64(50)))
+	g.Expect(*exp.Spec.TrafficControl.Interval).To(gomega.Equal("1m"))
+	g.Expect(exp.Spec.Analysis.AnalyticsService).To(gomega.Equal("http://iter8-analytics.iter8"))
+
+	defaults := Defaults
+	defer func() { Defaults = defaults }()
+	Defaults.MaxTrafficPercentage = 80
+	Defaults.SampleSize = 20
+
+	exp = newTestExperiment()
+	stepSize := float64(5)
+	exp.Spec.TrafficControl.TrafficStepSize = &stepSize
+	exp.Spec.Analysis.SuccessCriteria = []SuccessCriterion{
+		{MetricName: "iter8_latency", ToleranceType: ToleranceTypeThreshold, Tolerance: 0.2},
+	}
+	exp.Default()
+	g.Expect(*exp.Spec.TrafficControl.Strategy).To(gomega.Equal(StrategyCheckAndIncrement))
+	g.Expect(*exp.Spec.TrafficControl.MaxTrafficPercentage).To(gomega.Equal(float64(80)))
+	g.Expect(*exp.Spec.TrafficControl.TrafficStepSize).To(gomega.Equal(stepSize))
+	g.Expect(*exp.Spec.Analysis.SuccessCriteria[0].SampleSize).To(gomega.Equal(20))
+	g.Expect(*exp.Spec.Analysis.SuccessCriteria[0].StopOnFailure).To(gomega.BeFalse())
+}
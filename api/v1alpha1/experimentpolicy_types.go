@@ -0,0 +1,148 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// +kubebuilder:object:root=true
+
+// ExperimentPolicy is the Schema for the experimentpolicies API.
+// It sets limits the experiments of the selected namespaces cannot override.
+// +kubebuilder:resource:scope=Cluster,categories=iter8
+type ExperimentPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ExperimentPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ExperimentPolicyList contains a list of ExperimentPolicy
+type ExperimentPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExperimentPolicy `json:"items"`
+}
+
+// ExperimentPolicySpec defines the limits set on experiments
+type ExperimentPolicySpec struct {
+	// Namespaces the policy applies to. Applies to all namespaces if empty
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+
+	// MaxTrafficPercentage is the maximum total traffic percentage of the candidates
+	// +optional
+	MaxTrafficPercentage *float64 `json:"maxTrafficPercentage,omitempty"`
+
+	// MinInterval is the minimum interval between iterations, also applied to the dwell of each step of a ramp
+	// +optional
+	MinInterval *string `json:"minInterval,omitempty"`
+
+	// RequiredSuccessCriteria are the metrics each experiment must assess with a success criterion
+	// +optional
+	RequiredSuccessCriteria []string `json:"requiredSuccessCriteria,omitempty"`
+
+	// AllowedStrategies are the strategies experiments may use. All strategies are allowed if empty
+	// +optional
+	AllowedStrategies []string `json:"allowedStrategies,omitempty"`
+}
+
+// AppliesTo tells whether the policy applies to the experiments of the namespace
+func (p *ExperimentPolicy) AppliesTo(namespace string) bool {
+	if len(p.Spec.Namespaces) == 0 {
+		return true
+	}
+	for _, ns := range p.Spec.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// Violations returns the effective settings of the experiment violating the policy
+func (p *ExperimentPolicy) Violations(exp *Experiment) field.ErrorList {
+	allErrs := field.ErrorList{}
+	trafficPath := field.NewPath("spec", "trafficControl")
+	t := &exp.Spec.TrafficControl
+
+	if max := p.Spec.MaxTrafficPercentage; max != nil && t.GetMaxTrafficPercentage() > *max {
+		allErrs = append(allErrs, field.Invalid(trafficPath.Child("maxTrafficPercentage"), t.GetMaxTrafficPercentage(),
+			fmt.Sprintf("exceeds %v set by policy %s", *max, p.Name)))
+	}
+
+	if p.Spec.MinInterval != nil {
+		// an unparsable minimum interval sets no limit
+		if min, err := time.ParseDuration(*p.Spec.MinInterval); err == nil {
+			if interval, err := t.GetIntervalDuration(); err == nil && interval < min {
+				allErrs = append(allErrs, field.Invalid(trafficPath.Child("interval"), t.GetInterval(),
+					fmt.Sprintf("is shorter than %s set by policy %s", *p.Spec.MinInterval, p.Name)))
+			}
+			for i, step := range t.Steps {
+				if step.Dwell == nil {
+					continue
+				}
+				if dwell, err := time.ParseDuration(*step.Dwell); err == nil && dwell < min {
+					allErrs = append(allErrs, field.Invalid(trafficPath.Child("steps").Index(i).Child("dwell"), *step.Dwell,
+						fmt.Sprintf("is shorter than %s set by policy %s", *p.Spec.MinInterval, p.Name)))
+				}
+			}
+		}
+	}
+
+	strategy := t.GetStrategy()
+	if len(p.Spec.AllowedStrategies) > 0 && !containsString(p.Spec.AllowedStrategies, strategy) {
+		allErrs = append(allErrs, field.NotSupported(trafficPath.Child("strategy"), strategy, p.Spec.AllowedStrategies))
+	}
+
+	if len(p.Spec.RequiredSuccessCriteria) > 0 {
+		if strategy == StrategyIncrementWithoutCheck {
+			allErrs = append(allErrs, field.Invalid(trafficPath.Child("strategy"), strategy,
+				fmt.Sprintf("does not assess the success criteria required by policy %s", p.Name)))
+		}
+		criteria := map[string]bool{}
+		for _, criterion := range exp.Spec.Analysis.SuccessCriteria {
+			criteria[criterion.MetricName] = true
+		}
+		for _, metric := range p.Spec.RequiredSuccessCriteria {
+			if !criteria[metric] {
+				allErrs = append(allErrs, field.Required(field.NewPath("spec", "analysis", "successCriteria"),
+					fmt.Sprintf("must assess metric %s required by policy %s", metric, p.Name)))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	SchemeBuilder.Register(&ExperimentPolicy{}, &ExperimentPolicyList{})
+}
@@ -0,0 +1,76 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPolicyViolations(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	maxTraffic, minInterval := 50.0, "1m"
+	policy := &ExperimentPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "production"},
+		Spec: ExperimentPolicySpec{
+			Namespaces:              []string{"bookinfo"},
+			MaxTrafficPercentage:    &maxTraffic,
+			MinInterval:             &minInterval,
+			RequiredSuccessCriteria: []string{"iter8_error_rate"},
+			AllowedStrategies:       []string{StrategyCheckAndIncrement},
+		},
+	}
+	g.Expect(policy.AppliesTo("bookinfo")).To(gomega.BeTrue())
+	g.Expect(policy.AppliesTo("default")).To(gomega.BeFalse())
+
+	strategy, interval, max := StrategyCheckAndIncrement, "2m", 40.0
+	exp := newTestExperiment()
+	exp.Spec.TrafficControl = TrafficControl{Strategy: &strategy, Interval: &interval, MaxTrafficPercentage: &max}
+	exp.Spec.Analysis.SuccessCriteria = []SuccessCriterion{{MetricName: "iter8_error_rate", ToleranceType: ToleranceTypeThreshold}}
+	g.Expect(policy.Violations(exp)).To(gomega.BeEmpty())
+
+	strategy, interval, max = StrategyIncrementWithoutCheck, "30s", 100.0
+	exp.Spec.Analysis.SuccessCriteria = nil
+	fields := []string{}
+	for _, err := range policy.Violations(exp) {
+		fields = append(fields, err.Field)
+	}
+	g.Expect(fields).To(gomega.ConsistOf(
+		"spec.trafficControl.maxTrafficPercentage",
+		"spec.trafficControl.interval",
+		"spec.trafficControl.strategy",
+		"spec.trafficControl.strategy",
+		"spec.analysis.successCriteria",
+	))
+
+	// without a max traffic percentage, the last step of the ramp is the effective maximum
+	strategy, interval = StrategyCheckAndIncrement, "2m"
+	exp.Spec.Analysis.SuccessCriteria = []SuccessCriterion{{MetricName: "iter8_error_rate", ToleranceType: ToleranceTypeThreshold}}
+	exp.Spec.TrafficControl.MaxTrafficPercentage = nil
+	dwell := "10s"
+	exp.Spec.TrafficControl.Steps = []TrafficStep{{Percentage: 20, Dwell: &dwell}, {Percentage: 80}}
+	fields = []string{}
+	for _, err := range policy.Violations(exp) {
+		fields = append(fields, err.Field)
+	}
+	g.Expect(fields).To(gomega.ConsistOf(
+		"spec.trafficControl.maxTrafficPercentage",
+		"spec.trafficControl.steps[0].dwell",
+	))
+}
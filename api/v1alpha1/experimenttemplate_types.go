@@ -0,0 +1,117 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +kubebuilder:object:root=true
+
+// ExperimentTemplate is the Schema for the experimenttemplates API.
+// It holds trafficControl and analysis settings shared by the experiments referencing it.
+// +kubebuilder:categories=all,iter8
+type ExperimentTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ExperimentTemplateSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ExperimentTemplateList contains a list of ExperimentTemplate
+type ExperimentTemplateList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExperimentTemplate `json:"items"`
+}
+
+// ExperimentTemplateSpec defines the settings shared by experiments
+type ExperimentTemplateSpec struct {
+	// TrafficControl defines parameters for controlling the traffic
+	// +optional
+	TrafficControl TrafficControl `json:"trafficControl,omitempty"`
+
+	// Analysis parameters
+	// +optional
+	Analysis Analysis `json:"analysis,omitempty"`
+}
+
+// MergeTemplate sets the trafficControl and analysis fields left unset in the spec to the values of the template
+func (s *ExperimentSpec) MergeTemplate(template *ExperimentTemplateSpec) {
+	tpl := template.DeepCopy()
+
+	t := &s.TrafficControl
+	if t.Strategy == nil {
+		t.Strategy = tpl.TrafficControl.Strategy
+	}
+	if t.MaxTrafficPercentage == nil {
+		t.MaxTrafficPercentage = tpl.TrafficControl.MaxTrafficPercentage
+	}
+	if t.TrafficStepSize == nil {
+		t.TrafficStepSize = tpl.TrafficControl.TrafficStepSize
+	}
+	if t.Interval == nil {
+		t.Interval = tpl.TrafficControl.Interval
+	}
+	if t.MaxIterations == nil {
+		t.MaxIterations = tpl.TrafficControl.MaxIterations
+	}
+	if t.Steps == nil {
+		t.Steps = tpl.TrafficControl.Steps
+	}
+	if t.Match == nil {
+		t.Match = tpl.TrafficControl.Match
+	}
+	if t.Mirror == nil {
+		t.Mirror = tpl.TrafficControl.Mirror
+	}
+	if t.ApprovalGates == nil {
+		t.ApprovalGates = tpl.TrafficControl.ApprovalGates
+	}
+	if t.OnSuccess == nil {
+		t.OnSuccess = tpl.TrafficControl.OnSuccess
+	}
+	if t.OnDrift == nil {
+		t.OnDrift = tpl.TrafficControl.OnDrift
+	}
+
+	a := &s.Analysis
+	if a.AnalyticsService == "" {
+		a.AnalyticsService = tpl.Analysis.AnalyticsService
+	}
+	if a.GrafanaEndpoint == "" {
+		a.GrafanaEndpoint = tpl.Analysis.GrafanaEndpoint
+	}
+	if a.SuccessCriteria == nil {
+		a.SuccessCriteria = tpl.Analysis.SuccessCriteria
+	}
+	if a.FailurePolicy == nil {
+		a.FailurePolicy = tpl.Analysis.FailurePolicy
+	}
+}
+
+// ApplyTemplate merges the template into the spec of the experiment, as MergeTemplate does. The fields filled in
+// by the defaulting webhook yield to the template, and are defaulted again if the template leaves them unset
+func (r *Experiment) ApplyTemplate(template *ExperimentTemplateSpec) {
+	r.Spec.unsetDefaulted(r.defaultedFields())
+	r.Spec.MergeTemplate(template)
+	r.Spec.ApplyDefaults()
+}
+
+func init() {
+	SchemeBuilder.Register(&ExperimentTemplate{}, &ExperimentTemplateList{})
+}
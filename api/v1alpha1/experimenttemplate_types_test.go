@@ -0,0 +1,114 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestMergeTemplate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	strategy, interval, maxIterations := StrategyCheckAndIncrement, "30s", 10
+	template := &ExperimentTemplateSpec{
+		TrafficControl: TrafficControl{Strategy: &strategy, Interval: &interval, MaxIterations: &maxIterations},
+		Analysis: Analysis{
+			AnalyticsService: "http://analytics.team",
+			SuccessCriteria:  []SuccessCriterion{{MetricName: "iter8_latency", ToleranceType: ToleranceTypeThreshold}},
+		},
+	}
+
+	exp := newTestExperiment()
+	ownInterval := "1m"
+	exp.Spec.TrafficControl.Interval = &ownInterval
+	exp.Spec.MergeTemplate(template)
+	g.Expect(*exp.Spec.TrafficControl.Strategy).To(gomega.Equal(StrategyCheckAndIncrement))
+	g.Expect(*exp.Spec.TrafficControl.Interval).To(gomega.Equal("1m"))
+	g.Expect(*exp.Spec.TrafficControl.MaxIterations).To(gomega.Equal(10))
+	g.Expect(exp.Spec.Analysis.AnalyticsService).To(gomega.Equal("http://analytics.team"))
+	g.Expect(exp.Spec.Analysis.SuccessCriteria).To(gomega.HaveLen(1))
+
+	// the template is copied, not shared
+	*exp.Spec.TrafficControl.MaxIterations = 20
+	g.Expect(*template.TrafficControl.MaxIterations).To(gomega.Equal(10))
+}
+
+func TestApplyTemplate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	maxIterations := 10
+	template := &ExperimentTemplateSpec{
+		TrafficControl: TrafficControl{MaxIterations: &maxIterations},
+		Analysis: Analysis{
+			SuccessCriteria: []SuccessCriterion{{MetricName: "iter8_latency", ToleranceType: ToleranceTypeThreshold}},
+		},
+	}
+
+	// the defaults are filled in, and recorded as such since the experiment references a template
+	exp := newTestExperiment()
+	exp.Spec.Template = "team-defaults"
+	ownInterval := "1m"
+	exp.Spec.TrafficControl.Interval = &ownInterval
+	exp.Default()
+	g.Expect(*exp.Spec.TrafficControl.MaxIterations).To(gomega.Equal(Defaults.MaxIterations))
+	g.Expect(*exp.Spec.TrafficControl.Strategy).To(gomega.Equal(StrategyIncrementWithoutCheck))
+	g.Expect(exp.GetAnnotations()).To(gomega.HaveKeyWithValue(DefaultedAnnotation,
+		gomega.ContainSubstring("trafficControl.maxIterations")))
+	g.Expect(exp.GetAnnotations()[DefaultedAnnotation]).NotTo(gomega.ContainSubstring("trafficControl.interval"))
+
+	// the template overrides the defaults but not the fields of the experiment
+	exp.ApplyTemplate(template)
+	g.Expect(*exp.Spec.TrafficControl.MaxIterations).To(gomega.Equal(10))
+	g.Expect(*exp.Spec.TrafficControl.Interval).To(gomega.Equal("1m"))
+	g.Expect(*exp.Spec.TrafficControl.Strategy).To(gomega.Equal(Defaults.Strategy))
+	g.Expect(*exp.Spec.Analysis.SuccessCriteria[0].SampleSize).To(gomega.Equal(Defaults.SampleSize))
+
+	// without a template, no field is recorded
+	exp = newTestExperiment()
+	exp.Default()
+	g.Expect(exp.GetAnnotations()).NotTo(gomega.HaveKey(DefaultedAnnotation))
+}
+
+func TestValidateTemplated(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	interval, maxPercent, maxFailures := "0s", float64(150), 0
+	template := &ExperimentTemplateSpec{
+		TrafficControl: TrafficControl{
+			Interval:             &interval,
+			MaxTrafficPercentage: &maxPercent,
+			Steps:                []TrafficStep{{Percentage: 20}, {Percentage: 10}},
+			ApprovalGates:        []int{50, 10},
+		},
+		Analysis: Analysis{FailurePolicy: &FailurePolicy{MaxConsecutiveFailures: &maxFailures}},
+	}
+
+	exp := newTestExperiment()
+	exp.Spec.Template = "team-defaults"
+	exp.Default()
+	g.Expect(exp.ValidateTemplated()).To(gomega.Succeed())
+
+	// the settings supplied by the template are validated once merged
+	exp.ApplyTemplate(template)
+	err := exp.ValidateTemplated()
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.interval")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.maxTrafficPercentage")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.steps[1].percentage")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.trafficControl.approvalGates[1]")))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("spec.analysis.failurePolicy.maxConsecutiveFailures")))
+}
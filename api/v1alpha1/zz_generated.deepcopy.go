@@ -20,16 +20,139 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"k8s.io/api/core/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Analysis) DeepCopyInto(out *Analysis) {
+	*out = *in
+	if in.SuccessCriteria != nil {
+		in, out := &in.SuccessCriteria, &out.SuccessCriteria
+		*out = make([]SuccessCriterion, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(FailurePolicy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Analysis.
+func (in *Analysis) DeepCopy() *Analysis {
+	if in == nil {
+		return nil
+	}
+	out := new(Analysis)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Approval) DeepCopyInto(out *Approval) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Approval.
+func (in *Approval) DeepCopy() *Approval {
+	if in == nil {
+		return nil
+	}
+	out := new(Approval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CandidateRecord) DeepCopyInto(out *CandidateRecord) {
+	*out = *in
+	if in.SuccessCriteria != nil {
+		in, out := &in.SuccessCriteria, &out.SuccessCriteria
+		*out = make([]CriterionOutcome, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CandidateRecord.
+func (in *CandidateRecord) DeepCopy() *CandidateRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(CandidateRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CandidateStatus) DeepCopyInto(out *CandidateStatus) {
+	*out = *in
+	in.AssessmentSummary.DeepCopyInto(&out.AssessmentSummary)
+	in.AnalysisState.DeepCopyInto(&out.AnalysisState)
+	if in.SuccessCriteria != nil {
+		in, out := &in.SuccessCriteria, &out.SuccessCriteria
+		*out = make([]CriterionOutcome, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CandidateStatus.
+func (in *CandidateStatus) DeepCopy() *CandidateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CandidateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CookieMatch) DeepCopyInto(out *CookieMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CookieMatch.
+func (in *CookieMatch) DeepCopy() *CookieMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(CookieMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CriterionOutcome) DeepCopyInto(out *CriterionOutcome) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CriterionOutcome.
+func (in *CriterionOutcome) DeepCopy() *CriterionOutcome {
+	if in == nil {
+		return nil
+	}
+	out := new(CriterionOutcome)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Experiment) DeepCopyInto(out *Experiment) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = make(ExperimentMetrics, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Experiment.
@@ -82,9 +205,161 @@ func (in *ExperimentList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentMetric) DeepCopyInto(out *ExperimentMetric) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentMetric.
+func (in *ExperimentMetric) DeepCopy() *ExperimentMetric {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentMetric)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in ExperimentMetrics) DeepCopyInto(out *ExperimentMetrics) {
+	{
+		in := &in
+		*out = make(ExperimentMetrics, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentMetrics.
+func (in ExperimentMetrics) DeepCopy() ExperimentMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentMetrics)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentPolicy) DeepCopyInto(out *ExperimentPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentPolicy.
+func (in *ExperimentPolicy) DeepCopy() *ExperimentPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExperimentPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentPolicyList) DeepCopyInto(out *ExperimentPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExperimentPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentPolicyList.
+func (in *ExperimentPolicyList) DeepCopy() *ExperimentPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExperimentPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentPolicySpec) DeepCopyInto(out *ExperimentPolicySpec) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxTrafficPercentage != nil {
+		in, out := &in.MaxTrafficPercentage, &out.MaxTrafficPercentage
+		*out = new(float64)
+		**out = **in
+	}
+	if in.MinInterval != nil {
+		in, out := &in.MinInterval, &out.MinInterval
+		*out = new(string)
+		**out = **in
+	}
+	if in.RequiredSuccessCriteria != nil {
+		in, out := &in.RequiredSuccessCriteria, &out.RequiredSuccessCriteria
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedStrategies != nil {
+		in, out := &in.AllowedStrategies, &out.AllowedStrategies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentPolicySpec.
+func (in *ExperimentPolicySpec) DeepCopy() *ExperimentPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExperimentSpec) DeepCopyInto(out *ExperimentSpec) {
 	*out = *in
+	in.TargetService.DeepCopyInto(&out.TargetService)
+	in.TrafficControl.DeepCopyInto(&out.TrafficControl)
+	in.Analysis.DeepCopyInto(&out.Analysis)
+	if in.RoutingReference != nil {
+		in, out := &in.RoutingReference, &out.RoutingReference
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.TTLSecondsAfterFinished != nil {
+		in, out := &in.TTLSecondsAfterFinished, &out.TTLSecondsAfterFinished
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ArchiveStatus != nil {
+		in, out := &in.ArchiveStatus, &out.ArchiveStatus
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentSpec.
@@ -100,6 +375,48 @@ func (in *ExperimentSpec) DeepCopy() *ExperimentSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExperimentStatus) DeepCopyInto(out *ExperimentStatus) {
 	*out = *in
+	in.Status.DeepCopyInto(&out.Status)
+	in.LastIncrementTime.DeepCopyInto(&out.LastIncrementTime)
+	in.AnalysisState.DeepCopyInto(&out.AnalysisState)
+	in.AssessmentSummary.DeepCopyInto(&out.AssessmentSummary)
+	out.TrafficSplit = in.TrafficSplit
+	if in.Candidates != nil {
+		in, out := &in.Candidates, &out.Candidates
+		*out = make([]CandidateStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]IterationRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Approvals != nil {
+		in, out := &in.Approvals, &out.Approvals
+		*out = make([]Approval, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RoutingSnapshot != nil {
+		in, out := &in.RoutingSnapshot, &out.RoutingSnapshot
+		*out = new(RoutingSnapshot)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PreviousRuns != nil {
+		in, out := &in.PreviousRuns, &out.PreviousRuns
+		*out = make([]RunSummary, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.AnalyticsFailingSince != nil {
+		in, out := &in.AnalyticsFailingSince, &out.AnalyticsFailingSince
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentStatus.
@@ -111,3 +428,442 @@ func (in *ExperimentStatus) DeepCopy() *ExperimentStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentTemplate) DeepCopyInto(out *ExperimentTemplate) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentTemplate.
+func (in *ExperimentTemplate) DeepCopy() *ExperimentTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExperimentTemplate) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentTemplateList) DeepCopyInto(out *ExperimentTemplateList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ExperimentTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentTemplateList.
+func (in *ExperimentTemplateList) DeepCopy() *ExperimentTemplateList {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentTemplateList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ExperimentTemplateList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExperimentTemplateSpec) DeepCopyInto(out *ExperimentTemplateSpec) {
+	*out = *in
+	in.TrafficControl.DeepCopyInto(&out.TrafficControl)
+	in.Analysis.DeepCopyInto(&out.Analysis)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExperimentTemplateSpec.
+func (in *ExperimentTemplateSpec) DeepCopy() *ExperimentTemplateSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ExperimentTemplateSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FailurePolicy) DeepCopyInto(out *FailurePolicy) {
+	*out = *in
+	if in.MaxConsecutiveFailures != nil {
+		in, out := &in.MaxConsecutiveFailures, &out.MaxConsecutiveFailures
+		*out = new(int)
+		**out = **in
+	}
+	if in.MaxOutageDuration != nil {
+		in, out := &in.MaxOutageDuration, &out.MaxOutageDuration
+		*out = new(string)
+		**out = **in
+	}
+	if in.Action != nil {
+		in, out := &in.Action, &out.Action
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FailurePolicy.
+func (in *FailurePolicy) DeepCopy() *FailurePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(FailurePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeaderMatch) DeepCopyInto(out *HeaderMatch) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeaderMatch.
+func (in *HeaderMatch) DeepCopy() *HeaderMatch {
+	if in == nil {
+		return nil
+	}
+	out := new(HeaderMatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IterationRecord) DeepCopyInto(out *IterationRecord) {
+	*out = *in
+	in.Timestamp.DeepCopyInto(&out.Timestamp)
+	out.TrafficSplit = in.TrafficSplit
+	if in.Candidates != nil {
+		in, out := &in.Candidates, &out.Candidates
+		*out = make([]CandidateRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conclusions != nil {
+		in, out := &in.Conclusions, &out.Conclusions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IterationRecord.
+func (in *IterationRecord) DeepCopy() *IterationRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(IterationRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatchRule) DeepCopyInto(out *MatchRule) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make([]HeaderMatch, len(*in))
+		copy(*out, *in)
+	}
+	if in.Cookie != nil {
+		in, out := &in.Cookie, &out.Cookie
+		*out = new(CookieMatch)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatchRule.
+func (in *MatchRule) DeepCopy() *MatchRule {
+	if in == nil {
+		return nil
+	}
+	out := new(MatchRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MirrorPhase) DeepCopyInto(out *MirrorPhase) {
+	*out = *in
+	if in.Percentage != nil {
+		in, out := &in.Percentage, &out.Percentage
+		*out = new(int)
+		**out = **in
+	}
+	if in.Iterations != nil {
+		in, out := &in.Iterations, &out.Iterations
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MirrorPhase.
+func (in *MirrorPhase) DeepCopy() *MirrorPhase {
+	if in == nil {
+		return nil
+	}
+	out := new(MirrorPhase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoutingSnapshot) DeepCopyInto(out *RoutingSnapshot) {
+	*out = *in
+	if in.VirtualService != nil {
+		in, out := &in.VirtualService, &out.VirtualService
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DestinationRule != nil {
+		in, out := &in.DestinationRule, &out.DestinationRule
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Traffic != nil {
+		in, out := &in.Traffic, &out.Traffic
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backends != nil {
+		in, out := &in.Backends, &out.Backends
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = new(runtime.RawExtension)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoutingSnapshot.
+func (in *RoutingSnapshot) DeepCopy() *RoutingSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(RoutingSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunSummary) DeepCopyInto(out *RunSummary) {
+	*out = *in
+	out.TrafficSplit = in.TrafficSplit
+	if in.Conclusions != nil {
+		in, out := &in.Conclusions, &out.Conclusions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSummary.
+func (in *RunSummary) DeepCopy() *RunSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(RunSummary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SuccessCriterion) DeepCopyInto(out *SuccessCriterion) {
+	*out = *in
+	if in.SampleSize != nil {
+		in, out := &in.SampleSize, &out.SampleSize
+		*out = new(int)
+		**out = **in
+	}
+	if in.StopOnFailure != nil {
+		in, out := &in.StopOnFailure, &out.StopOnFailure
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SuccessCriterion.
+func (in *SuccessCriterion) DeepCopy() *SuccessCriterion {
+	if in == nil {
+		return nil
+	}
+	out := new(SuccessCriterion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Summary) DeepCopyInto(out *Summary) {
+	*out = *in
+	if in.Conclusions != nil {
+		in, out := &in.Conclusions, &out.Conclusions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Summary.
+func (in *Summary) DeepCopy() *Summary {
+	if in == nil {
+		return nil
+	}
+	out := new(Summary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetService) DeepCopyInto(out *TargetService) {
+	*out = *in
+	if in.ObjectReference != nil {
+		in, out := &in.ObjectReference, &out.ObjectReference
+		*out = new(v1.ObjectReference)
+		**out = **in
+	}
+	if in.Candidates != nil {
+		in, out := &in.Candidates, &out.Candidates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetService.
+func (in *TargetService) DeepCopy() *TargetService {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficControl) DeepCopyInto(out *TrafficControl) {
+	*out = *in
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxTrafficPercentage != nil {
+		in, out := &in.MaxTrafficPercentage, &out.MaxTrafficPercentage
+		*out = new(float64)
+		**out = **in
+	}
+	if in.TrafficStepSize != nil {
+		in, out := &in.TrafficStepSize, &out.TrafficStepSize
+		*out = new(float64)
+		**out = **in
+	}
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(string)
+		**out = **in
+	}
+	if in.MaxIterations != nil {
+		in, out := &in.MaxIterations, &out.MaxIterations
+		*out = new(int)
+		**out = **in
+	}
+	if in.Steps != nil {
+		in, out := &in.Steps, &out.Steps
+		*out = make([]TrafficStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Match != nil {
+		in, out := &in.Match, &out.Match
+		*out = make([]MatchRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Mirror != nil {
+		in, out := &in.Mirror, &out.Mirror
+		*out = new(MirrorPhase)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ApprovalGates != nil {
+		in, out := &in.ApprovalGates, &out.ApprovalGates
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.OnSuccess != nil {
+		in, out := &in.OnSuccess, &out.OnSuccess
+		*out = new(string)
+		**out = **in
+	}
+	if in.OnDrift != nil {
+		in, out := &in.OnDrift, &out.OnDrift
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficControl.
+func (in *TrafficControl) DeepCopy() *TrafficControl {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficControl)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficSplit) DeepCopyInto(out *TrafficSplit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficSplit.
+func (in *TrafficSplit) DeepCopy() *TrafficSplit {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficSplit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrafficStep) DeepCopyInto(out *TrafficStep) {
+	*out = *in
+	if in.Dwell != nil {
+		in, out := &in.Dwell, &out.Dwell
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrafficStep.
+func (in *TrafficStep) DeepCopy() *TrafficStep {
+	if in == nil {
+		return nil
+	}
+	out := new(TrafficStep)
+	in.DeepCopyInto(out)
+	return out
+}
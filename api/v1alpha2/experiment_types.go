@@ -0,0 +1,694 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha2
+
+import (
+	duckv1alpha1 "github.com/knative/pkg/apis/duck/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// +kubebuilder:object:root=true
+
+// Experiment is the Schema for the experiments API
+// +kubebuilder:subresource:status
+// +kubebuilder:categories=all,iter8
+// +kubebuilder:printcolumn:name="phase",type="string",JSONPath=".status.phase",description="Phase of the experiment",format="byte"
+// +kubebuilder:printcolumn:name="status",type="string",JSONPath=".status.message",description="Detailed Status of the experiment",format="byte"
+// +kubebuilder:printcolumn:name="baseline",type="string",JSONPath=".spec.targetService.baseline",description="Name of baseline",format="byte"
+// +kubebuilder:printcolumn:name="percentage",type="integer",JSONPath=".status.trafficSplitPercentage.baseline",description="Traffic percentage for baseline",format="int32"
+// +kubebuilder:printcolumn:name="candidate",type="string",JSONPath=".spec.targetService.candidate",description="Name of candidate",format="byte"
+// +kubebuilder:printcolumn:name="percentage",type="integer",JSONPath=".status.trafficSplitPercentage.candidate",description="Traffic percentage for candidate",format="int32"
+type Experiment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ExperimentSpec   `json:"spec,omitempty"`
+	Status ExperimentStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// ExperimentList contains a list of Experiment
+type ExperimentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Experiment `json:"items"`
+}
+
+type AssessmentType string
+
+const (
+	AssessmentOverrideSuccess AssessmentType = "override_success"
+	AssessmentOverrideFailure AssessmentType = "override_failure"
+	AssessmentNull            AssessmentType = ""
+)
+
+type ActionType string
+
+const (
+	ActionPause  ActionType = "pause"
+	ActionResume ActionType = "resume"
+)
+
+type CleanUpType string
+
+const (
+	CleanUpDelete CleanUpType = "delete"
+	CleanUpNull   CleanUpType = ""
+)
+
+const (
+	StrategyIncrementWithoutCheck string = "increment_without_check"
+	StrategyCheckAndIncrement     string = "check_and_increment"
+	StrategyEpsilonGreedy         string = "epsilon_greedy"
+)
+
+// ExperimentSpec defines the desired state of Experiment
+type ExperimentSpec struct {
+	// TargetService is a reference to an object to use as target service
+	TargetService TargetService `json:"targetService"`
+
+	// Template is the name of an ExperimentTemplate in the namespace of the experiment.
+	// Its trafficControl and analysis settings apply to the fields the experiment leaves unset
+	// +optional
+	Template string `json:"template,omitempty"`
+
+	// TrafficControl defines parameters for controlling the traffic
+	// +optional
+	TrafficControl TrafficControl `json:"trafficControl,omitempty"`
+
+	// Analysis parameters
+	// +optional
+	Analysis Analysis `json:"analysis,omitempty"`
+
+	// Assessment is a flag to terminate experiment with action
+	// +optional.
+	//+kubebuilder:validation:Enum={override_success,override_failure}
+	Assessment AssessmentType `json:"assessment,omitempty"`
+
+	// Action pauses or resumes the experiment. While "pause" is set, no iteration is run and the traffic
+	// is left as is; setting "resume" or removing the action resumes the experiment.
+	// +optional
+	//+kubebuilder:validation:Enum={pause,resume}
+	Action ActionType `json:"action,omitempty"`
+
+	// DryRun runs the experiment without ever changing the routing or the targets: the traffic split of each
+	// iteration and the outcome are computed and recorded in the status and events, but not applied.
+	// It may not be changed while the experiment is running
+	// +optional
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// CleanUp is a flag to determine the action to take at the end of experiment
+	// +optional.
+	//+kubebuilder:validation:Enum=delete
+	CleanUp CleanUpType `json:"cleanup,omitempty"`
+
+	// RoutingProvider is the routing backend driving the traffic of the target service. Options:
+	// "istio": Istio virtual services and destination rules, the default for Kubernetes services
+	// "knative": the traffic block of the Knative service, the default for Knative services
+	// "smi": an SMI TrafficSplit whose backends are the baseline and candidate services, for Kubernetes services
+	// "gateway": the backendRefs of the Gateway API HTTPRoute named by routingReference, for Kubernetes services
+	// +optional
+	//+kubebuilder:validation:Enum={istio,knative,smi,gateway}
+	RoutingProvider string `json:"routingProvider,omitempty"`
+
+	// RoutingReference provides references to routing rules set by users.
+	// The gateway routing provider drives the HTTPRoute it names, and creates it if it does not exist
+	// +optional
+	RoutingReference *corev1.ObjectReference `json:"routingReference,omitempty"`
+
+	// TTLSecondsAfterFinished is the time the experiment is kept once completed before it is deleted.
+	// Defaults to the cluster default; the experiment is kept until deleted by the user if neither is set
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// ArchiveStatus exports the final status of the experiment to a config map before it is deleted
+	// at the end of TTLSecondsAfterFinished. Defaults to the cluster default
+	// +optional
+	ArchiveStatus *bool `json:"archiveStatus,omitempty"`
+}
+
+// TargetService defines what to watch in the controller
+type TargetService struct {
+	// Ref is the reference to the service whose versions are compared
+	Ref TargetReference `json:"ref"`
+
+	// Baseline tells the name of baseline
+	Baseline string `json:"baseline,omitempty"`
+
+	// Candidate tells the name of candidate
+	Candidate string `json:"candidate,omitempty"`
+
+	// Candidates tells the names of candidates when more than one version is compared against the baseline
+	// +optional
+	Candidates []string `json:"candidates,omitempty"`
+}
+
+// TargetReference identifies the target service of an experiment
+type TargetReference struct {
+	// APIVersion of the service: "v1" for a Kubernetes service or "serving.knative.dev/v1alpha1" for a Knative service
+	APIVersion string `json:"apiVersion"`
+
+	// Kind of the service
+	// +optional
+	Kind string `json:"kind,omitempty"`
+
+	// Name of the service
+	Name string `json:"name"`
+
+	// Namespace of the service. Defaults to the namespace of the experiment
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type Phase string
+
+const (
+	PhaseInitializing Phase = "Initializing"
+	PhasePause        Phase = "Pause"
+	PhaseProgressing  Phase = "Progressing"
+	PhaseCompleted    Phase = "Completed"
+)
+
+// ExperimentStatus defines the observed state of Experiment
+type ExperimentStatus struct {
+	// inherits duck/v1alpha1 Status, which currently provides:
+	// * ObservedGeneration - the 'Generation' of the Service that was last processed by the controller.
+	// * Conditions - the latest available observations of a resource's current state.
+	duckv1alpha1.Status `json:",inline"`
+
+	// StartTimestamp is the time when the experiment starts
+	// +optional
+	StartTimestamp *metav1.MicroTime `json:"startTimestamp,omitempty"`
+
+	// EndTimestamp is the time when the experiment completes
+	// +optional
+	EndTimestamp *metav1.MicroTime `json:"endTimestamp,omitempty"`
+
+	// LastIncrementTime is the last time the traffic has been incremented
+	LastIncrementTime metav1.Time `json:"lastIncrementTime,omitempty"`
+
+	// CurrentIteration is the current iteration number
+	CurrentIteration int `json:"currentIteration,omitempty"`
+
+	// AnalysisState is the last analysis state
+	// +kubebuilder:pruning:PreserveUnknownFields
+	AnalysisState runtime.RawExtension `json:"analysisState,omitempty"`
+
+	// GrafanaURL is the url to the Grafana Dashboard
+	GrafanaURL string `json:"grafanaURL,omitempty"`
+
+	// AssessmentSummary returned by the last analyis
+	AssessmentSummary Summary `json:"assessment,omitempty"`
+
+	// TrafficSplit tells the current traffic spliting between baseline and candidate
+	TrafficSplit TrafficSplit `json:"trafficSplitPercentage,omitempty"`
+
+	// Candidates tells the current state of each candidate
+	Candidates []CandidateStatus `json:"candidates,omitempty"`
+
+	// Winner is the candidate selected at the end of the experiment
+	Winner string `json:"winner,omitempty"`
+
+	// History records the most recent iterations of the experiment
+	// +optional
+	History []IterationRecord `json:"history,omitempty"`
+
+	// CompactedIterations is the number of iterations dropped from History
+	// +optional
+	CompactedIterations int `json:"compactedIterations,omitempty"`
+
+	// TemplateGeneration is the generation of the template last merged into the effective spec
+	// +optional
+	TemplateGeneration int64 `json:"templateGeneration,omitempty"`
+
+	// Approvals records the approval gates passed so far
+	// +optional
+	Approvals []Approval `json:"approvals,omitempty"`
+
+	// RoutingSnapshot is the routing of the target service before the experiment
+	// +optional
+	RoutingSnapshot *RoutingSnapshot `json:"routingSnapshot,omitempty"`
+
+	// PreviousRuns summarizes the runs completed before the experiment was rerun, oldest first
+	// +optional
+	PreviousRuns []RunSummary `json:"previousRuns,omitempty"`
+
+	// LastRerun is the value of the iter8.tools/rerun annotation that started the current run
+	// +optional
+	LastRerun string `json:"lastRerun,omitempty"`
+
+	// AnalyticsFailures is the number of consecutive failed analyses
+	// +optional
+	AnalyticsFailures int `json:"analyticsFailures,omitempty"`
+
+	// AnalyticsFailingSince is the time of the first of the consecutive failed analyses
+	// +optional
+	AnalyticsFailingSince *metav1.Time `json:"analyticsFailingSince,omitempty"`
+
+	// FailurePolicyAction is the action of the failure policy taken once its limits were reached
+	// +optional
+	FailurePolicyAction string `json:"failurePolicyAction,omitempty"`
+
+	// Metrics are the definitions of the metrics referenced by the success criteria,
+	// as read from the iter8 metrics config map when the experiment started
+	// +optional
+	Metrics []MetricSnapshot `json:"metrics,omitempty"`
+
+	// Phase marks the Phase the experiment is at
+	Phase Phase `json:"phase,omitempty"`
+
+	// Message specifies message to show in the kubectl printer
+	Message string `json:"message,omitempty"`
+}
+
+// RoutingSnapshot holds the routing of the target service before the experiment took it over,
+// restored when the experiment fails, is aborted or is deleted
+type RoutingSnapshot struct {
+	// VirtualService is the spec of the Istio virtual service
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	VirtualService *runtime.RawExtension `json:"virtualService,omitempty"`
+
+	// DestinationRule is the spec of the Istio destination rule
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	DestinationRule *runtime.RawExtension `json:"destinationRule,omitempty"`
+
+	// Traffic is the traffic block of the Knative service
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Traffic *runtime.RawExtension `json:"traffic,omitempty"`
+
+	// Backends are the backends of the SMI traffic split
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Backends *runtime.RawExtension `json:"backends,omitempty"`
+
+	// Rules are the rules of the Gateway API HTTPRoute
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Rules *runtime.RawExtension `json:"rules,omitempty"`
+}
+
+// RunSummary summarizes a completed run of the experiment
+type RunSummary struct {
+	// StartTimestamp is the timestamp when the run started
+	// +optional
+	StartTimestamp *metav1.MicroTime `json:"startTimestamp,omitempty"`
+
+	// EndTimestamp is the timestamp when the run completed
+	// +optional
+	EndTimestamp *metav1.MicroTime `json:"endTimestamp,omitempty"`
+
+	// Iterations is the number of iterations of the run
+	Iterations int `json:"iterations"`
+
+	// TrafficSplit tells the traffic split at the end of the run
+	TrafficSplit TrafficSplit `json:"trafficSplitPercentage"`
+
+	// Winner is the candidate selected at the end of the run
+	// +optional
+	Winner string `json:"winner,omitempty"`
+
+	// Conclusions returned by the last analysis of the run
+	// +optional
+	Conclusions []string `json:"conclusions,omitempty"`
+
+	// Message is the message of the experiment at the end of the run
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// TrafficSplit tells the traffic percentage of baseline and the total traffic percentage of candidates
+type TrafficSplit struct {
+	Baseline  int `json:"baseline"`
+	Candidate int `json:"candidate"`
+}
+
+// CandidateStatus defines the observed state of one candidate
+type CandidateStatus struct {
+	// Name of the candidate
+	Name string `json:"name"`
+
+	// TrafficPercentage is the current traffic percentage of the candidate
+	TrafficPercentage int `json:"trafficPercentage"`
+
+	// AssessmentSummary returned by the last analysis of the candidate
+	AssessmentSummary Summary `json:"assessment,omitempty"`
+
+	// AnalysisState is the last analysis state of the candidate
+	// +kubebuilder:pruning:PreserveUnknownFields
+	AnalysisState runtime.RawExtension `json:"analysisState,omitempty"`
+
+	// SuccessCriteria tells the outcome of each success criterion in the last analysis of the candidate
+	// +optional
+	SuccessCriteria []CriterionOutcome `json:"successCriteria,omitempty"`
+}
+
+// CriterionOutcome tells the outcome of a success criterion for a candidate
+type CriterionOutcome struct {
+	// MetricName is the name of the metric of the criterion
+	MetricName string `json:"metricName"`
+
+	// Met indicates whether or not the criterion is met
+	Met bool `json:"met"`
+
+	// Abort indicates whether or not the criterion requires the experiment to be aborted
+	Abort bool `json:"abort,omitempty"`
+}
+
+// IterationRecord records the outcome of one iteration of the experiment
+type IterationRecord struct {
+	// Iteration is the number of the iteration
+	Iteration int `json:"iteration"`
+
+	// Timestamp is the time when the iteration ended
+	Timestamp metav1.Time `json:"timestamp"`
+
+	// TrafficSplit tells the traffic split at the end of the iteration
+	TrafficSplit TrafficSplit `json:"trafficSplitPercentage"`
+
+	// Candidates tells the traffic and the outcome of the success criteria of each candidate
+	// +optional
+	Candidates []CandidateRecord `json:"candidates,omitempty"`
+
+	// Conclusions returned by the analytics service
+	// +optional
+	Conclusions []string `json:"conclusions,omitempty"`
+
+	// Error that interrupted the iteration, if any
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// Approval records who approved an approval gate and when
+type Approval struct {
+	// Gate is the approved traffic percentage
+	Gate int `json:"gate"`
+
+	// Approver is the name given in the iter8.tools/approver annotation
+	// +optional
+	Approver string `json:"approver,omitempty"`
+
+	// Time when the approval was recorded
+	Time metav1.Time `json:"time"`
+}
+
+// CandidateRecord records the state of one candidate at the end of an iteration
+type CandidateRecord struct {
+	// Name of the candidate
+	Name string `json:"name"`
+
+	// TrafficPercentage is the traffic percentage of the candidate
+	TrafficPercentage int `json:"trafficPercentage"`
+
+	// SuccessCriteria tells the outcome of each success criterion for the candidate
+	// +optional
+	SuccessCriteria []CriterionOutcome `json:"successCriteria,omitempty"`
+}
+
+// MetricSnapshot stores the definition of a metric used by the experiment
+type MetricSnapshot struct {
+	// Name of the metric
+	Name string `json:"name"`
+
+	// QueryTemplate is the query template for metric
+	QueryTemplate string `json:"queryTemplate"`
+
+	// SampleSizeTemplate is the query template for sample size
+	SampleSizeTemplate string `json:"sampleSizeTemplate"`
+
+	// IsCounter indicates metric is a monotonically increasing counter
+	IsCounter bool `json:"isCounter"`
+
+	// AbsentValue is default value when data source does not provide a value
+	AbsentValue string `json:"absentValue"`
+}
+
+type TrafficControl struct {
+	// Strategy is the strategy used for experiment. Options:
+	// "check_and_increment": get decision on traffic increament from analytics
+	// "increment_without_check": increase traffic each interval without calling analytics
+	// +optional. Defaults to the cluster default, "check_and_increment" unless configured.
+	//+kubebuilder:validation:Enum={check_and_increment,increment_without_check,epsilon_greedy}
+	Strategy *string `json:"strategy,omitempty"`
+
+	// MaxTrafficPercentage is the maximum traffic ratio to send to the candidate.
+	// Defaults to the last step of Steps if set, or else to the cluster default (50)
+	// +optional
+	MaxTrafficPercentage *float64 `json:"maxTrafficPercentage,omitempty"`
+
+	// TrafficStepSize is the traffic increment per interval. Defaults to the cluster default (2.0)
+	// +optional
+	TrafficStepSize *float64 `json:"trafficStepSize,omitempty"`
+
+	// Interval is the time in second before the next increment. Defaults to the cluster default (1m)
+	// +optional
+	Interval *string `json:"interval,omitempty"`
+
+	// Maximum number of iterations for this experiment. Defaults to the cluster default (100).
+	// +optional
+	MaxIterations *int `json:"maxIterations,omitempty"`
+
+	// Steps is an explicit ramp of the total candidate traffic, used instead of TrafficStepSize.
+	// Analytics-driven strategies never go beyond the next step of the ramp.
+	// +optional
+	Steps []TrafficStep `json:"steps,omitempty"`
+
+	// Match routes user segments to candidates regardless of the traffic split. Istio targets only
+	// +optional
+	Match []MatchRule `json:"match,omitempty"`
+
+	// Mirror mirrors the baseline traffic to the candidate for the first iterations,
+	// assessed against the success criteria before any traffic is shifted. Istio targets only
+	// +optional
+	Mirror *MirrorPhase `json:"mirror,omitempty"`
+
+	// ApprovalGates are total candidate traffic percentages the ramp holds at until approved,
+	// in increasing order. See the iter8.tools/approve annotation
+	// +optional
+	ApprovalGates []int `json:"approvalGates,omitempty"`
+
+	// Determines how the traffic must be split at the end of the experiment; options:
+	// "baseline": all traffic goes to the baseline version;
+	// "candidate": all traffic goes to the candidate version;
+	// "both": traffic is split across baseline and candidate.
+	// Defaults to the cluster default (“candidate”)
+	// +optional
+	//+kubebuilder:validation:Enum={baseline,candidate,both}
+	OnSuccess *string `json:"onSuccess,omitempty"`
+
+	// OnDrift determines what happens when the routing rules are changed by someone else during the experiment; options:
+	// "restore": the traffic split of the experiment is restored;
+	// "adopt": the changed traffic split is recorded and the experiment goes on from it;
+	// "pause": the experiment is paused until the traffic split of the experiment is restored.
+	// Defaults to "restore"
+	// +optional
+	//+kubebuilder:validation:Enum={restore,adopt,pause}
+	OnDrift *string `json:"onDrift,omitempty"`
+}
+
+// TrafficStep is one step of a traffic ramp
+type TrafficStep struct {
+	// Percentage is the total traffic percentage of the candidates at this step
+	Percentage int `json:"percentage"`
+
+	// Dwell is the time to stay at this step before the next iteration. Defaults to Interval
+	// +optional
+	Dwell *string `json:"dwell,omitempty"`
+}
+
+// MirrorPhase mirrors baseline traffic to the candidate before the traffic ramp
+type MirrorPhase struct {
+	// Percentage of the baseline requests mirrored to the candidate. Defaults to 100
+	// +optional
+	Percentage *int `json:"percentage,omitempty"`
+
+	// Iterations run on mirrored traffic before the traffic ramp starts. Defaults to 1
+	// +optional
+	Iterations *int `json:"iterations,omitempty"`
+}
+
+// MatchRule routes the requests of a user segment to a candidate.
+// A request matches the rule when it satisfies all of its conditions.
+type MatchRule struct {
+	// Candidate receiving the matching requests. Defaults to the first candidate
+	// +optional
+	Candidate string `json:"candidate,omitempty"`
+
+	// Headers the request must carry
+	// +optional
+	Headers []HeaderMatch `json:"headers,omitempty"`
+
+	// Cookie the request must carry
+	// +optional
+	Cookie *CookieMatch `json:"cookie,omitempty"`
+
+	// URIPrefix the request path must start with
+	// +optional
+	URIPrefix string `json:"uriPrefix,omitempty"`
+}
+
+// HeaderMatch matches a request header. Exactly one of Exact, Prefix and Regex must be set
+type HeaderMatch struct {
+	// Name of the header
+	Name string `json:"name"`
+
+	// Exact value of the header
+	// +optional
+	Exact string `json:"exact,omitempty"`
+
+	// Prefix of the header value
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// Regex the header value must match
+	// +optional
+	Regex string `json:"regex,omitempty"`
+}
+
+// CookieMatch matches a cookie of the request by its exact value
+type CookieMatch struct {
+	// Name of the cookie
+	Name string `json:"name"`
+
+	// Value of the cookie
+	Value string `json:"value"`
+}
+
+type Analysis struct {
+	// AnalyticsService endpoint
+	AnalyticsService string `json:"analyticsService,omitempty"`
+
+	// Grafana Dashboard endpoint
+	GrafanaEndpoint string `json:"grafanaEndpoint,omitempty"`
+
+	// List of criteria for assessing the candidate version
+	SuccessCriteria []SuccessCriterion `json:"successCriteria,omitempty"`
+
+	// FailurePolicy limits the failures of the analytics service tolerated by the experiment.
+	// Without it, failed analyses are retried until the analytics service recovers
+	// +optional
+	FailurePolicy *FailurePolicy `json:"failurePolicy,omitempty"`
+}
+
+// FailurePolicy limits the failures of the analytics service tolerated by the experiment.
+// Failed analyses are retried with exponential backoff until a limit is reached.
+type FailurePolicy struct {
+	// MaxConsecutiveFailures is the number of consecutive failed analyses after which the action is taken
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxConsecutiveFailures *int `json:"maxConsecutiveFailures,omitempty"`
+
+	// MaxOutageDuration is how long analyses may keep failing before the action is taken, e.g. "10m"
+	// +optional
+	MaxOutageDuration *string `json:"maxOutageDuration,omitempty"`
+
+	// Action is the action taken once a limit is reached. Options:
+	// "hold": keep the traffic split as is and keep retrying
+	// "rollback": roll back to the baseline and fail the experiment
+	// "increment_without_check": go on increasing the traffic without calling the analytics service.
+	// The candidates are not assessed anymore: the run only promotes one with an override_success assessment
+	// +optional. Default is "hold".
+	//+kubebuilder:validation:Enum={hold,rollback,increment_without_check}
+	Action *string `json:"action,omitempty"`
+}
+
+type Summary struct {
+	// Overall summary based on all success criteria
+	Conclusions []string `json:"conclusions,omitempty"`
+
+	// Indicates whether or not all success criteria for assessing the candidate version
+	// have been met
+	AllSuccessCriteriaMet bool `json:"all_success_criteria_met,omitempty"`
+
+	// Indicates whether or not the experiment must be aborted based on the success criteria
+	AbortExperiment bool `json:"abort_experiment,omitempty"`
+}
+
+type ToleranceType string
+
+const (
+	ToleranceTypeDelta     ToleranceType = "delta"
+	ToleranceTypeThreshold ToleranceType = "threshold"
+)
+
+// SuccessCriterion specifies the criteria for an experiment to succeed
+type SuccessCriterion struct {
+	// Name of the metric to which the criterion applies. Options:
+	MetricName string `json:"metricName"`
+
+	// 	Tolerance type. Options:
+	// "delta": compares the candidate against the baseline version with respect to the metric;
+	// "threshold": checks the candidate with respect to the metric
+	//+kubebuilder:validation:Enum={threshold,delta}
+	ToleranceType ToleranceType `json:"toleranceType"`
+
+	// Value to check
+	Tolerance float64 `json:"tolerance"`
+
+	// Minimum number of data points required to make a decision based on this criterion;
+	// If not specified, the cluster default (10) is used
+	// +optional
+	SampleSize *int `json:"sampleSize,omitempty"`
+
+	// Indicates whether or not the experiment must finish if this criterion is not satisfied;
+	// defaults to false
+	// +optional
+	StopOnFailure *bool `json:"stopOnFailure,omitempty"`
+}
+
+const (
+	// ExperimentConditionReady has status True when the Experiment has finished controlling traffic
+	ExperimentConditionReady = duckv1alpha1.ConditionReady
+
+	// ExperimentConditionTargetsProvided has status True when the Experiment detects all elements specified in targetService
+	ExperimentConditionTargetsProvided duckv1alpha1.ConditionType = "TargetsProvided"
+
+	// ExperimentConditionAnalyticsServiceNormal has status True when the analytics service is operating normally
+	ExperimentConditionAnalyticsServiceNormal duckv1alpha1.ConditionType = "AnalyticsServiceNormal"
+
+	// ExperimentConditionMetricsSynced has status True when metrics are successfully synced with config map
+	ExperimentConditionMetricsSynced duckv1alpha1.ConditionType = "MetricsSynced"
+
+	// ExperimentConditionExperimentCompleted has status True when the experiment is completed
+	ExperimentConditionExperimentCompleted duckv1alpha1.ConditionType = "ExperimentCompleted"
+
+	// ExperimentConditionExperimentSucceeded has status True when the experiment is succeeded
+	ExperimentConditionExperimentSucceeded duckv1alpha1.ConditionType = "ExperimentSucceeded"
+
+	// ExperimentConditionRoutingRulesReady has status True when routing rules are ready
+	ExperimentConditionRoutingRulesReady duckv1alpha1.ConditionType = "RoutingRulesReady"
+
+	// ExperimentConditionPaused has status True when the experiment is paused by the user
+	ExperimentConditionPaused duckv1alpha1.ConditionType = "Paused"
+
+	// ExperimentConditionAwaitingApproval has status True when the ramp is held at an approval gate
+	ExperimentConditionAwaitingApproval duckv1alpha1.ConditionType = "AwaitingApproval"
+
+	// ExperimentConditionPolicyViolated has status True when the experiment violates an experiment policy
+	ExperimentConditionPolicyViolated duckv1alpha1.ConditionType = "PolicyViolated"
+
+	// ExperimentConditionRoutingDrifted has status True when the experiment is paused because its routing rules were changed
+	ExperimentConditionRoutingDrifted duckv1alpha1.ConditionType = "RoutingDrifted"
+)
+
+func init() {
+	SchemeBuilder.Register(&Experiment{}, &ExperimentList{})
+}
@@ -0,0 +1,106 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+const (
+	// approveAnnotation approves the approval gate given as its value, e.g. "10"
+	approveAnnotation = "iter8.tools/approve"
+
+	// approverAnnotation names who approved the gate
+	approverAnnotation = "iter8.tools/approver"
+)
+
+// checkApproval records the approval given through the approval annotations.
+// It returns true while the ramp is held at a gate, in which case no iteration must be run.
+// An assessment given by the user ends the experiment regardless of the gate.
+func (r *ExperimentReconciler) checkApproval(context context.Context, instance *iter8v1alpha1.Experiment) (bool, error) {
+	annotations := instance.GetAnnotations()
+	value, ok := annotations[approveAnnotation]
+	if !ok {
+		return isHeld(instance), nil
+	}
+
+	gate, err := strconv.Atoi(value)
+	if err != nil || !hasGate(instance, gate) {
+		Logger(context).Info("IgnoredApproval", "gate", value)
+		return isHeld(instance), nil
+	}
+	if instance.Status.IsApproved(gate) {
+		return isHeld(instance), nil
+	}
+
+	// a gate approved ahead of the ramp is recorded, but does not release the gate the ramp is held at
+	pending, _ := instance.PendingGate()
+	approver := annotations[approverAnnotation]
+	instance.Status.Approvals = append(instance.Status.Approvals, iter8v1alpha1.Approval{
+		Gate:     gate,
+		Approver: approver,
+		Time:     metav1.Now(),
+	})
+	if gate == pending {
+		r.MarkApproved(context, instance, "Gate %d%% approved by %s", gate, approver)
+	} else {
+		Logger(context).Info("ApprovedAhead", "gate", gate, "approver", approver)
+	}
+	return isHeld(instance), nil
+}
+
+// capToGate caps the candidate percentages to the lowest approval gate not approved yet
+func capToGate(instance *iter8v1alpha1.Experiment, percents []int) []int {
+	if gate, ok := instance.PendingGate(); ok {
+		return capTraffic(percents, gate)
+	}
+	return percents
+}
+
+// checkGate holds the ramp once the candidates reach the lowest approval gate not approved yet
+func (r *ExperimentReconciler) checkGate(context context.Context, instance *iter8v1alpha1.Experiment, percents []int) {
+	gate, ok := instance.PendingGate()
+	if !ok {
+		return
+	}
+
+	total := 0
+	for _, percent := range percents {
+		total += percent
+	}
+	if total >= gate {
+		r.MarkAwaitingApproval(context, instance, "Traffic held at %d%% until approved", gate)
+	}
+}
+
+// isHeld tells whether the ramp is held at a gate and no assessment is given
+func isHeld(instance *iter8v1alpha1.Experiment) bool {
+	return instance.Status.IsAwaitingApproval() && instance.Spec.Assessment == iter8v1alpha1.AssessmentNull
+}
+
+func hasGate(instance *iter8v1alpha1.Experiment, gate int) bool {
+	for _, g := range instance.Spec.TrafficControl.ApprovalGates {
+		if g == gate {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+)
+
+func TestCapToGate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	g.Expect(capToGate(instance, []int{30})).To(gomega.Equal([]int{30}))
+
+	instance.Spec.TrafficControl.ApprovalGates = []int{10, 50}
+	g.Expect(capToGate(instance, []int{30})).To(gomega.Equal([]int{10}))
+
+	instance.Status.Approvals = []iter8v1alpha1.Approval{{Gate: 10, Approver: "jason"}}
+	g.Expect(capToGate(instance, []int{30})).To(gomega.Equal([]int{30}))
+	g.Expect(capToGate(instance, []int{40, 40})).To(gomega.Equal([]int{25, 25}))
+
+	instance.Status.Approvals = append(instance.Status.Approvals, iter8v1alpha1.Approval{Gate: 50})
+	_, pending := instance.PendingGate()
+	g.Expect(pending).To(gomega.BeFalse())
+}
+
+func TestCheckApproval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := newTestContext()
+	r := newFakeReconciler(g)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Status.InitializeConditions()
+	instance.Spec.TrafficControl.ApprovalGates = []int{10, 50}
+	r.MarkAwaitingApproval(ctx, instance, "Traffic held at %d%% until approved", 10)
+
+	// approving a later gate does not release the ramp held at the pending one
+	instance.SetAnnotations(map[string]string{approveAnnotation: "50", approverAnnotation: "jason"})
+	held, err := r.checkApproval(ctx, instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(held).To(gomega.BeTrue())
+	g.Expect(instance.Status.IsApproved(50)).To(gomega.BeTrue())
+
+	instance.SetAnnotations(map[string]string{approveAnnotation: "10", approverAnnotation: "jason"})
+	held, err = r.checkApproval(ctx, instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(held).To(gomega.BeFalse())
+	_, pending := instance.PendingGate()
+	g.Expect(pending).To(gomega.BeFalse())
+}
@@ -0,0 +1,203 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+
+	runtime "k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/iter8-tools/iter8-controller/pkg/analytics"
+	"github.com/iter8-tools/iter8-controller/pkg/analytics/checkandincrement"
+	"github.com/iter8-tools/iter8-controller/pkg/analytics/epsilongreedy"
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// candidateSubsets returns the names of the routing subsets of the candidates, in the order of
+// TargetService.GetCandidates(). A single candidate keeps the plain Candidate subset.
+func candidateSubsets(instance *iter8v1alpha1.Experiment) []string {
+	candidates := instance.Spec.TargetService.GetCandidates()
+	if len(candidates) == 1 {
+		return []string{Candidate}
+	}
+	subsets := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		subsets[i] = Candidate + "-" + candidate
+	}
+	return subsets
+}
+
+// splitTraffic spreads total evenly over n candidates; the remainder goes to the first ones
+func splitTraffic(total, n int) []int {
+	out := make([]int, n)
+	if n == 0 {
+		return out
+	}
+	for i := range out {
+		out[i] = total / n
+		if i < total%n {
+			out[i]++
+		}
+	}
+	return out
+}
+
+// capTraffic scales candidate percentages down proportionally when their sum exceeds max
+func capTraffic(percents []int, max int) []int {
+	total := 0
+	for _, percent := range percents {
+		total += percent
+	}
+	if total <= max {
+		return percents
+	}
+
+	out := make([]int, len(percents))
+	for i, percent := range percents {
+		out[i] = percent * max / total
+	}
+	return out
+}
+
+// incrementTraffic returns the total candidate traffic after one increment from current:
+// the next step of the ramp if specified, or else one step size per candidate up to the max traffic percentage
+func incrementTraffic(traffic *iter8v1alpha1.TrafficControl, current, n int) int {
+	if next, ok := traffic.NextStep(current); ok {
+		return next
+	}
+	total := current + int(traffic.GetStepSize())*n
+	if maxPercent := int(traffic.GetMaxTrafficPercentage()); total > maxPercent {
+		total = maxPercent
+	}
+	return total
+}
+
+// capToNextStep caps the recommended candidate percentages to the next step of the ramp, if specified
+func capToNextStep(traffic *iter8v1alpha1.TrafficControl, current int, percents []int) []int {
+	if next, ok := traffic.NextStep(current); ok {
+		return capTraffic(percents, next)
+	}
+	return percents
+}
+
+// analyzeCandidates assesses each candidate against the baseline with the analytics service.
+// It returns the traffic percentage recommended for each candidate, in the order of
+// TargetService.GetCandidates(), and aggregates the assessments into Status.AssessmentSummary.
+func (r *ExperimentReconciler) analyzeCandidates(context context.Context, instance *iter8v1alpha1.Experiment,
+	baseline interface{}, candidates []interface{}) ([]int, error) {
+	log := Logger(context)
+
+	var analyticsService analytics.AnalyticsService
+	switch getStrategy(instance) {
+	case checkandincrement.Strategy:
+		analyticsService = checkandincrement.GetService()
+	case epsilongreedy.Strategy:
+		analyticsService = epsilongreedy.GetService()
+	}
+
+	names := instance.Spec.TargetService.GetCandidates()
+	percents := make([]int, len(candidates))
+	summary := iter8v1alpha1.Summary{AbortExperiment: true}
+	for i, candidate := range candidates {
+		status := instance.Status.GetCandidateStatus(names[i])
+
+		// The analytics service reads the last state of the pair from the experiment status
+		instance.Status.AnalysisState = status.AnalysisState
+		payload, err := analyticsService.MakeRequest(instance, baseline, candidate)
+		if err != nil {
+			r.MarkAnalyticsServiceError(context, instance, "Can Not Compose Payload: %v", err)
+			return nil, err
+		}
+		response, err := analyticsService.Invoke(log, instance.Spec.Analysis.GetServiceEndpoint(), payload, analyticsService.GetPath())
+		if err != nil {
+			r.MarkAnalyticsServiceError(context, instance, "%s", err.Error())
+			return nil, err
+		}
+
+		if response.LastState == nil {
+			status.AnalysisState.Raw = []byte("{}")
+		} else {
+			lastState, err := json.Marshal(response.LastState)
+			if err != nil {
+				r.MarkAnalyticsServiceError(context, instance, "ErrorAnalyticsResponse: %v", err)
+				return nil, err
+			}
+			status.AnalysisState = runtime.RawExtension{Raw: lastState}
+		}
+		instance.Status.AnalysisState = status.AnalysisState
+		status.AssessmentSummary = response.Assessment.Summary
+		status.SuccessCriteria = make([]iter8v1alpha1.CriterionOutcome, len(response.Assessment.SuccessCriteria))
+		for j, criterion := range response.Assessment.SuccessCriteria {
+			status.SuccessCriteria[j] = iter8v1alpha1.CriterionOutcome{
+				MetricName: criterion.MetricName,
+				Met:        criterion.SuccessCriterionMet,
+				Abort:      criterion.AbortExperiment,
+			}
+		}
+
+		log.Info("NewTraffic", "candidate", names[i], "percentage", response.Candidate.TrafficPercentage)
+		if !response.Assessment.Summary.AbortExperiment {
+			percents[i] = int(response.Candidate.TrafficPercentage)
+		}
+
+		// The experiment goes on as long as one candidate is still eligible
+		summary.AllSuccessCriteriaMet = summary.AllSuccessCriteriaMet || response.Assessment.Summary.AllSuccessCriteriaMet
+		summary.AbortExperiment = summary.AbortExperiment && response.Assessment.Summary.AbortExperiment
+		for _, conclusion := range response.Assessment.Summary.Conclusions {
+			if len(names) > 1 {
+				conclusion = names[i] + ": " + conclusion
+			}
+			summary.Conclusions = append(summary.Conclusions, conclusion)
+		}
+	}
+
+	instance.Status.AssessmentSummary = summary
+	r.MarkAnalyticsServiceRunning(context, instance)
+
+	return capTraffic(percents, int(instance.Spec.TrafficControl.GetMaxTrafficPercentage())), nil
+}
+
+// setTrafficSplit records the traffic percentages of baseline and candidates in the status
+func setTrafficSplit(instance *iter8v1alpha1.Experiment, baseline int, candidates []int) {
+	total := 0
+	for i, name := range instance.Spec.TargetService.GetCandidates() {
+		instance.Status.GetCandidateStatus(name).TrafficPercentage = candidates[i]
+		total += candidates[i]
+	}
+	instance.Status.TrafficSplit.Baseline = baseline
+	instance.Status.TrafficSplit.Candidate = total
+}
+
+// selectWinner picks the candidate to promote at the end of the experiment: the one with the most
+// traffic among the candidates meeting all success criteria, earlier candidates winning ties.
+// All candidates are eligible when the candidates are not assessed or success is overridden.
+func selectWinner(instance *iter8v1alpha1.Experiment) string {
+	assessed := getStrategy(instance) != iter8v1alpha1.StrategyIncrementWithoutCheck &&
+		instance.Spec.Assessment != iter8v1alpha1.AssessmentOverrideSuccess
+
+	winner, best := "", -1
+	for _, name := range instance.Spec.TargetService.GetCandidates() {
+		status := instance.Status.GetCandidateStatus(name)
+		if assessed && !status.AssessmentSummary.AllSuccessCriteriaMet {
+			continue
+		}
+		if status.TrafficPercentage > best {
+			winner, best = name, status.TrafficPercentage
+		}
+	}
+	return winner
+}
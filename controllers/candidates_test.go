@@ -0,0 +1,87 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestCandidateSubsets(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Spec.TargetService = iter8v1alpha1.TargetService{
+		ObjectReference: &corev1.ObjectReference{Name: "reviews"},
+		Baseline:        "reviews-v1",
+		Candidate:       "reviews-v2",
+	}
+	g.Expect(candidateSubsets(instance)).To(gomega.Equal([]string{Candidate}))
+
+	instance.Spec.TargetService.Candidates = []string{"reviews-v2", "reviews-v3"}
+	g.Expect(candidateSubsets(instance)).To(gomega.Equal([]string{"candidate-reviews-v2", "candidate-reviews-v3"}))
+}
+
+func TestSplitTraffic(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	g.Expect(splitTraffic(10, 1)).To(gomega.Equal([]int{10}))
+	g.Expect(splitTraffic(10, 3)).To(gomega.Equal([]int{4, 3, 3}))
+	g.Expect(splitTraffic(10, 0)).To(gomega.BeEmpty())
+}
+
+func TestCapTraffic(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	g.Expect(capTraffic([]int{20, 30}, 50)).To(gomega.Equal([]int{20, 30}))
+	g.Expect(capTraffic([]int{40, 40}, 50)).To(gomega.Equal([]int{25, 25}))
+	g.Expect(capTraffic([]int{60, 20}, 40)).To(gomega.Equal([]int{30, 10}))
+}
+
+func TestIncrementTraffic(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	stepSize, maxPercent := 10.0, 25.0
+	traffic := &iter8v1alpha1.TrafficControl{TrafficStepSize: &stepSize, MaxTrafficPercentage: &maxPercent}
+	g.Expect(incrementTraffic(traffic, 0, 2)).To(gomega.Equal(20))
+	g.Expect(incrementTraffic(traffic, 20, 2)).To(gomega.Equal(25))
+
+	traffic.Steps = []iter8v1alpha1.TrafficStep{{Percentage: 1}, {Percentage: 5}, {Percentage: 25}}
+	g.Expect(incrementTraffic(traffic, 0, 2)).To(gomega.Equal(1))
+	g.Expect(incrementTraffic(traffic, 3, 2)).To(gomega.Equal(5))
+	g.Expect(incrementTraffic(traffic, 25, 2)).To(gomega.Equal(25))
+	g.Expect(capToNextStep(traffic, 1, []int{10, 10})).To(gomega.Equal([]int{2, 2}))
+}
+
+func TestSelectWinner(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Spec.TargetService = iter8v1alpha1.TargetService{
+		ObjectReference: &corev1.ObjectReference{Name: "reviews"},
+		Baseline:        "reviews-v1",
+		Candidates:      []string{"reviews-v2", "reviews-v3"},
+	}
+	instance.Spec.Analysis.SuccessCriteria = []iter8v1alpha1.SuccessCriterion{
+		{MetricName: "iter8_latency", ToleranceType: iter8v1alpha1.ToleranceTypeThreshold, Tolerance: 0.2},
+	}
+	instance.Status.Candidates = []iter8v1alpha1.CandidateStatus{
+		{Name: "reviews-v2", TrafficPercentage: 40},
+		{Name: "reviews-v3", TrafficPercentage: 20, AssessmentSummary: iter8v1alpha1.Summary{AllSuccessCriteriaMet: true}},
+	}
+	g.Expect(selectWinner(instance)).To(gomega.Equal("reviews-v3"))
+
+	instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideSuccess
+	g.Expect(selectWinner(instance)).To(gomega.Equal("reviews-v2"))
+}
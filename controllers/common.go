@@ -18,7 +18,6 @@ import (
 	"context"
 	"fmt"
 	"strconv"
-	"strings"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -92,17 +91,30 @@ func updateGrafanaURL(instance *iter8v1alpha1.Experiment, namespace string) {
 	if endTs == "" {
 		endTs = "now"
 	}
+	candidates := ""
+	for _, candidate := range instance.Spec.TargetService.GetCandidates() {
+		candidates += "&var-candidate=" + candidate
+	}
 	instance.Status.GrafanaURL = instance.Spec.Analysis.GetGrafanaEndpoint() +
 		"/d/eXPEaNnZz/iter8-application-metrics?" +
 		"var-namespace=" + namespace +
 		"&var-service=" + instance.Spec.TargetService.Name +
 		"&var-baseline=" + instance.Spec.TargetService.Baseline +
-		"&var-candidate=" + instance.Spec.TargetService.Candidate +
+		candidates +
 		"&from=" + instance.Status.StartTimestamp +
 		"&to=" + endTs
 }
 
 func getStrategy(instance *iter8v1alpha1.Experiment) string {
+	// the failure policy gave up on the analytics service
+	if instance.Status.FailurePolicyAction == iter8v1alpha1.FailureActionIncrementWithoutCheck {
+		return iter8v1alpha1.StrategyIncrementWithoutCheck
+	}
+	return specStrategy(instance)
+}
+
+// specStrategy returns the strategy set by the experiment, regardless of its failure policy
+func specStrategy(instance *iter8v1alpha1.Experiment) string {
 	strategy := instance.Spec.TrafficControl.GetStrategy()
 	if strategy != iter8v1alpha1.StrategyIncrementWithoutCheck &&
 		(instance.Spec.Analysis.SuccessCriteria == nil || len(instance.Spec.Analysis.SuccessCriteria) == 0) {
@@ -112,12 +124,17 @@ func getStrategy(instance *iter8v1alpha1.Experiment) string {
 }
 
 func experimentSucceeded(instance *iter8v1alpha1.Experiment) bool {
-	if getStrategy(instance) == iter8v1alpha1.StrategyIncrementWithoutCheck {
-		return instance.Spec.Assessment == iter8v1alpha1.AssessmentOverrideSuccess ||
-			instance.Spec.Assessment == iter8v1alpha1.AssessmentNull
+	if instance.Spec.Assessment != iter8v1alpha1.AssessmentNull {
+		return instance.Spec.Assessment == iter8v1alpha1.AssessmentOverrideSuccess
 	}
-	return instance.Spec.Assessment == iter8v1alpha1.AssessmentOverrideSuccess ||
-		instance.Status.AssessmentSummary.AllSuccessCriteriaMet && instance.Spec.Assessment == iter8v1alpha1.AssessmentNull
+	if specStrategy(instance) == iter8v1alpha1.StrategyIncrementWithoutCheck {
+		return true
+	}
+	// candidates of a run which gave up on the analytics service are not assessed: only the user may promote them
+	if instance.Status.FailurePolicyAction == iter8v1alpha1.FailureActionIncrementWithoutCheck {
+		return false
+	}
+	return instance.Status.AssessmentSummary.AllSuccessCriteriaMet
 }
 
 func markExperimentCompleted(instance *iter8v1alpha1.Experiment) {
@@ -220,20 +237,17 @@ func readMetrics(context context.Context, c client.Client, instance *iter8v1alph
 		instance.Metrics[metric.Name] = m
 	}
 
-	err = c.Update(context, instance)
-	return err
-}
-
-func (r *ExperimentReconciler) executeStatusUpdate(ctx context.Context, instance *iter8v1alpha1.Experiment) (err error) {
-	trial := 3
-	for trial > 0 {
-		if err = r.Status().Update(ctx, instance); err == nil {
-			break
-		}
-		time.Sleep(time.Second * 5)
-		trial--
+	// Only the metrics are written: the spec held in memory may carry the fields of a template
+	stored := &iter8v1alpha1.Experiment{}
+	if err := c.Get(context, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, stored); err != nil {
+		return err
 	}
-	return
+	stored.Metrics = instance.Metrics
+	if err := c.Update(context, stored); err != nil {
+		return err
+	}
+	instance.ResourceVersion = stored.ResourceVersion
+	return nil
 }
 
 func removeExperimentLabel(objs ...runtime.Object) (err error) {
@@ -274,15 +288,12 @@ func setLabels(obj runtime.Object, newLabels map[string]string) error {
 	return nil
 }
 
-func validUpdateErr(err error) bool {
-	benignMsg := "the object has been modified"
-	return strings.Contains(err.Error(), benignMsg)
-}
-
 func withRecheckRequirement(instance *iter8v1alpha1.Experiment) bool {
 	analyticsCondition := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionAnalyticsServiceNormal)
 
-	if analyticsCondition != nil && analyticsCondition.Status == corev1.ConditionFalse {
+	// the analytics service is no longer called once the failure policy gave up on it
+	if analyticsCondition != nil && analyticsCondition.Status == corev1.ConditionFalse &&
+		instance.Status.FailurePolicyAction != iter8v1alpha1.FailureActionIncrementWithoutCheck {
 		return true
 	}
 
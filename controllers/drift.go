@@ -0,0 +1,93 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// isDrifted tells whether the live traffic split differs from the split recorded by the last iteration
+func isDrifted(instance *iter8v1alpha1.Experiment, baseline int, candidates []int) bool {
+	// no split is recorded before the first iteration
+	if instance.Status.CurrentIteration == 0 {
+		return false
+	}
+	if baseline != instance.Status.TrafficSplit.Baseline {
+		return true
+	}
+	for i, name := range instance.Spec.TargetService.GetCandidates() {
+		if candidates[i] != instance.Status.GetCandidateStatus(name).TrafficPercentage {
+			return true
+		}
+	}
+	return false
+}
+
+// recordedSplit returns the traffic split recorded by the last iteration
+func recordedSplit(instance *iter8v1alpha1.Experiment) (int, []int) {
+	names := instance.Spec.TargetService.GetCandidates()
+	candidates := make([]int, len(names))
+	for i, name := range names {
+		candidates[i] = instance.Status.GetCandidateStatus(name).TrafficPercentage
+	}
+	return instance.Status.TrafficSplit.Baseline, candidates
+}
+
+// checkDrift applies the drift policy of the experiment when the live traffic split, given by baseline and candidates,
+// differs from the recorded one. restore writes the given split to the routing rules.
+// It returns true while the experiment is paused by a drift, in which case no iteration must be run.
+// An assessment given by the user ends the experiment regardless of the drift.
+func (r *ExperimentReconciler) checkDrift(context context.Context, instance *iter8v1alpha1.Experiment,
+	baseline int, candidates []int, restore func(baseline int, candidates []int) error) (bool, error) {
+	if !isDrifted(instance, baseline, candidates) {
+		if instance.Status.IsRoutingDrifted() {
+			r.MarkRoutingRestored(context, instance, "Traffic split restored, baseline: %d, candidate: %d",
+				instance.Status.TrafficSplit.Baseline, instance.Status.TrafficSplit.Candidate)
+		}
+		return false, nil
+	}
+
+	if instance.Spec.Assessment != iter8v1alpha1.AssessmentNull {
+		return false, nil
+	}
+
+	onDrift := instance.Spec.TrafficControl.GetOnDrift()
+	switch onDrift {
+	case iter8v1alpha1.OnDriftPause:
+		if !instance.Status.IsRoutingDrifted() {
+			r.MarkRoutingDrifted(context, instance, "Traffic split changed to baseline: %d, candidates: %v", baseline, candidates)
+		}
+		return true, nil
+	case iter8v1alpha1.OnDriftAdopt:
+		setTrafficSplit(instance, baseline, candidates)
+		r.recordRoutingDrift(context, instance, "Adopted traffic split, baseline: %d, candidates: %v", baseline, candidates)
+	default:
+		recordedBaseline, recordedCandidates := recordedSplit(instance)
+		if err := restore(recordedBaseline, recordedCandidates); err != nil {
+			r.MarkRoutingRulesError(context, instance, "Fail to restore traffic: %v", err)
+			return true, err
+		}
+		r.recordRoutingDrift(context, instance, "Restored traffic split, baseline: %d, candidates: %v",
+			recordedBaseline, recordedCandidates)
+	}
+
+	if instance.Status.IsRoutingDrifted() {
+		r.MarkRoutingRestored(context, instance, "Resumed with drift policy %s", onDrift)
+	}
+	return false, nil
+}
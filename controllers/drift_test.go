@@ -0,0 +1,42 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+)
+
+func TestIsDrifted(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Spec.TargetService.Candidates = []string{"reviews-v2", "reviews-v3"}
+
+	// nothing is recorded before the first iteration
+	g.Expect(isDrifted(instance, 80, []int{10, 10})).To(gomega.BeFalse())
+
+	instance.Status.CurrentIteration = 2
+	setTrafficSplit(instance, 80, []int{10, 10})
+	g.Expect(isDrifted(instance, 80, []int{10, 10})).To(gomega.BeFalse())
+	g.Expect(isDrifted(instance, 80, []int{20, 0})).To(gomega.BeTrue())
+	g.Expect(isDrifted(instance, 100, []int{0, 0})).To(gomega.BeTrue())
+
+	baseline, candidates := recordedSplit(instance)
+	g.Expect(baseline).To(gomega.Equal(80))
+	g.Expect(candidates).To(gomega.Equal([]int{10, 10}))
+}
@@ -0,0 +1,130 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// syncDryRun runs the experiment without changing the routing or the targets. Each iteration computes the
+// traffic split as if the previous ones had been applied, and records it in the status and events only.
+func (r *ExperimentReconciler) syncDryRun(context context.Context, instance *iter8v1alpha1.Experiment,
+	router Router) (reconcile.Result, error) {
+	log := Logger(context)
+
+	baseline, candidates, err := router.Targets(context, instance)
+	if err != nil {
+		log.Info("retry in 5 secs", "err", err)
+		return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+	r.MarkTargetsFound(context, instance)
+
+	if router.Completed(instance) || instance.Spec.Assessment != iter8v1alpha1.AssessmentNull {
+		r.completeDryRun(context, instance)
+		return reconcile.Result{}, nil
+	}
+
+	// All the traffic goes to the baseline before the first iteration
+	if instance.Status.CurrentIteration == 0 {
+		setTrafficSplit(instance, 100, make([]int, len(candidates)))
+	}
+
+	now := time.Now()
+	traffic := instance.Spec.TrafficControl
+	interval, err := traffic.GetDwellDuration(instance.Status.TrafficSplit.Candidate)
+	if err != nil {
+		// the admission webhook validates the dwells of the experiment, and applyTemplate those of its template
+		log.Info("invalid dwell", "err", err)
+		return reconcile.Result{}, err
+	}
+	if !now.After(instance.Status.LastIncrementTime.Add(interval)) {
+		return reconcile.Result{RequeueAfter: instance.Status.LastIncrementTime.Add(interval).Sub(now)}, nil
+	}
+	if retryIn := analyticsRetryIn(instance, now); retryIn > 0 {
+		return reconcile.Result{RequeueAfter: retryIn}, nil
+	}
+
+	_, current := recordedSplit(instance)
+	total := instance.Status.TrafficSplit.Candidate
+	var newRolloutPercent []int
+	if getStrategy(instance) == iter8v1alpha1.StrategyIncrementWithoutCheck {
+		newRolloutPercent = splitTraffic(incrementTraffic(&traffic, total, len(candidates)), len(candidates))
+	} else {
+		percents, err := r.analyzeCandidates(context, instance, baseline, candidates)
+		if err != nil {
+			recordIteration(instance, err)
+			if r.checkFailureBudget(context, instance) == iter8v1alpha1.FailureActionRollback {
+				instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+				r.completeDryRun(context, instance)
+				return reconcile.Result{}, nil
+			}
+			log.Info("analytics failure", "failures", instance.Status.AnalyticsFailures, "err", err)
+			return analyticsRetry(instance), nil
+		}
+
+		if instance.Status.AssessmentSummary.AbortExperiment {
+			setTrafficSplit(instance, 100, make([]int, len(candidates)))
+			recordIteration(instance, nil)
+			r.MarkExperimentFailed(context, instance, "%s", "Dry run aborted, Traffic: AllToBaseline.")
+			return reconcile.Result{}, nil
+		}
+		newRolloutPercent = capToNextStep(&traffic, total, percents)
+	}
+	newRolloutPercent = capToGate(instance, newRolloutPercent)
+	if traffic.IsMirroring(instance.Status.CurrentIteration) {
+		newRolloutPercent = current
+	}
+
+	total = 0
+	for _, percent := range newRolloutPercent {
+		total += percent
+	}
+	setTrafficSplit(instance, 100-total, newRolloutPercent)
+	recordIteration(instance, nil)
+	instance.Status.CurrentIteration++
+	instance.Status.LastIncrementTime = metav1.NewTime(now)
+
+	r.MarkExperimentProgress(context, instance, true, "Dry run iteration %d Completed, baseline: %d, candidate: %d",
+		instance.Status.CurrentIteration, instance.Status.TrafficSplit.Baseline, instance.Status.TrafficSplit.Candidate)
+	r.checkGate(context, instance, newRolloutPercent)
+
+	if router.Completed(instance) {
+		return reconcile.Result{Requeue: true}, nil
+	}
+	interval, err = traffic.GetDwellDuration(instance.Status.TrafficSplit.Candidate)
+	return reconcile.Result{RequeueAfter: interval}, err
+}
+
+// completeDryRun records the outcome of the dry run and the traffic split it would end with
+func (r *ExperimentReconciler) completeDryRun(context context.Context, instance *iter8v1alpha1.Experiment) {
+	succeeded := experimentSucceeded(instance)
+	if succeeded {
+		instance.Status.Winner = selectWinner(instance)
+	}
+
+	setFinalTrafficSplit(instance, succeeded)
+	if succeeded {
+		r.MarkExperimentSucceeded(context, instance, "Dry run: %s", successMsg(instance))
+	} else {
+		r.MarkExperimentFailed(context, instance, "Dry run: %s", failureMsg(instance))
+	}
+}
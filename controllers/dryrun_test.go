@@ -0,0 +1,88 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestDryRunLeavesRoutingUntouched(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	strategy, maxIterations := iter8v1alpha1.StrategyIncrementWithoutCheck, 2
+	instance := &iter8v1alpha1.Experiment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "reviews-v2-rollout",
+			Namespace:         "bookinfo",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+		},
+		Spec: iter8v1alpha1.ExperimentSpec{
+			TargetService: iter8v1alpha1.TargetService{
+				ObjectReference: &corev1.ObjectReference{APIVersion: KubernetesService, Name: "reviews"},
+				Baseline:        "reviews-v1",
+				Candidate:       "reviews-v2",
+			},
+			TrafficControl: iter8v1alpha1.TrafficControl{Strategy: &strategy, MaxIterations: &maxIterations},
+			DryRun:         true,
+		},
+	}
+	r := newFakeReconciler(g, instance,
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: MetricsConfigMap, Namespace: Iter8Namespace}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "bookinfo"}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "reviews-v1", Namespace: "bookinfo",
+			Labels: map[string]string{"app": "reviews", "version": "v1"}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2", Namespace: "bookinfo",
+			Labels: map[string]string{"app": "reviews", "version": "v2"}}},
+	)
+	ctx := newTestContext()
+
+	versions := map[string]string{}
+	for _, name := range []string{"reviews-v1", "reviews-v2"} {
+		deployment := &appsv1.Deployment{}
+		g.Expect(r.Get(ctx, types.NamespacedName{Name: name, Namespace: "bookinfo"}, deployment)).To(gomega.Succeed())
+		versions[name] = deployment.ResourceVersion
+	}
+
+	// run every iteration at once, then the completion
+	for i := 0; i < maxIterations+3 && !isCompleted(instance); i++ {
+		instance.Status.LastIncrementTime = metav1.NewTime(time.Unix(0, 0))
+		_, err := r.reconcileExperiment(ctx, instance)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+	}
+	g.Expect(isCompleted(instance)).To(gomega.BeTrue())
+	g.Expect(instance.Status.CurrentIteration).To(gomega.Equal(maxIterations + 1))
+
+	// the split is only recorded: no routing rule is created and the targets are left as they were
+	vss, err := r.istioClient.NetworkingV1alpha3().VirtualServices("bookinfo").List(metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(vss.Items).To(gomega.BeEmpty())
+	drs, err := r.istioClient.NetworkingV1alpha3().DestinationRules("bookinfo").List(metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(drs.Items).To(gomega.BeEmpty())
+	for name, version := range versions {
+		deployment := &appsv1.Deployment{}
+		g.Expect(r.Get(ctx, types.NamespacedName{Name: name, Namespace: "bookinfo"}, deployment)).To(gomega.Succeed())
+		g.Expect(deployment.ResourceVersion).To(gomega.Equal(version))
+	}
+}
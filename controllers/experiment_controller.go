@@ -133,8 +133,9 @@ type ExperimentReconciler struct {
 	eventRecorder record.EventRecorder
 
 	istioClient istioclient.Interface
-	targets     *Targets
-	rules       *IstioRoutingRules
+
+	// MaxConcurrentReconciles is the maximum number of experiments reconciled in parallel. Defaults to 1
+	MaxConcurrentReconciles int
 }
 
 // Reconcile reads that state of the cluster for a Experiment object and makes changes based on the state read
@@ -143,12 +144,17 @@ type ExperimentReconciler struct {
 // +kubebuilder:rbac:groups=iter8.tools,resources=experiments/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=networking.istio.io,resources=destinationrules,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.istio.io,resources=virtualservices,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=split.smi-spec.io,resources=trafficsplits,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=serving.knative.dev,resources=services,verbs=get;list;watch;update;patch
 // +kubebuilder:rbac:groups=serving.knative.dev,resources=services/status,verbs=get
 // +kubebuilder:rbac:groups=serving.knative.dev,resources=revisions,verbs=get;list;watch
 // +kubebuilder:rbac:groups=serving.knative.dev,resources=revisions/status,verbs=get
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=deployments/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups=iter8.iter8.tools,resources=experimenttemplates,verbs=get;list;watch
+// +kubebuilder:rbac:groups=iter8.iter8.tools,resources=experimentpolicies,verbs=get;list;watch
 func (r *ExperimentReconciler) Reconcile(request reconcile.Request) (reconcile.Result, error) {
 	ctx := context.Background()
 
@@ -168,8 +174,30 @@ func (r *ExperimentReconciler) Reconcile(request reconcile.Request) (reconcile.R
 	log := log.WithValues("namespace", instance.Namespace, "name", instance.Name)
 	ctx = context.WithValue(ctx, loggerKey, log)
 
+	// Status changes accumulate in the instance and are written once the reconcile is over
+	original := instance.DeepCopy()
+	result, err := r.reconcileExperiment(ctx, instance)
+	if patchErr := r.patchStatus(ctx, original, instance); patchErr != nil {
+		if errors.IsConflict(patchErr) {
+			// retry from a fresh read, with backoff
+			log.Info("StatusConflict", "err", patchErr)
+			return reconcile.Result{Requeue: true}, nil
+		}
+		return reconcile.Result{}, patchErr
+	}
+	// The status update does not trigger a reconcile: come back to schedule the deletion of a newly completed experiment
+	if err == nil && result == (reconcile.Result{}) && !isCompleted(original) && isCompleted(instance) {
+		result.Requeue = true
+	}
+	return result, err
+}
+
+// reconcileExperiment runs one pass of the experiment. It only changes the status in memory
+func (r *ExperimentReconciler) reconcileExperiment(ctx context.Context, instance *iter8v1alpha1.Experiment) (reconcile.Result, error) {
+	log := Logger(ctx)
+
 	// Add finalizer to the experiment object
-	if err = addFinalizerIfAbsent(ctx, r, instance, Finalizer); err != nil {
+	if err := addFinalizerIfAbsent(ctx, r, instance, Finalizer); err != nil {
 		return reconcile.Result{}, err
 	}
 
@@ -181,8 +209,17 @@ func (r *ExperimentReconciler) Reconcile(request reconcile.Request) (reconcile.R
 	// // Stop right here if the experiment is completed.
 	completed := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionExperimentCompleted)
 	if completed != nil && completed.Status == corev1.ConditionTrue {
-		log.Info("RolloutCompleted", "Use a different name for experiment object to trigger a new experiment", "")
-		return reconcile.Result{}, nil
+		// A new run is started by setting the rerun annotation
+		rerun, err := r.checkRerun(ctx, instance)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !rerun {
+			log.Info("RolloutCompleted", "Set the "+rerunAnnotation+" annotation to a new value to rerun the experiment", "")
+			// Delete the experiment once its time to live is over
+			expiry, err := r.checkTTL(ctx, instance)
+			return reconcile.Result{RequeueAfter: expiry}, err
+		}
 	}
 
 	log.Info("reconciling")
@@ -212,40 +249,63 @@ func (r *ExperimentReconciler) Reconcile(request reconcile.Request) (reconcile.R
 
 	instance.Status.InitializeConditions()
 
+	// Merge the referenced template before any setting is used
+	if err := r.applyTemplate(ctx, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Freeze the experiment while it is paused by the user
+	if paused, err := r.checkPause(ctx, instance); paused || err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Hold the ramp at an approval gate until it is approved
+	if held, err := r.checkApproval(ctx, instance); held || err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// Hold the experiment while it violates an experiment policy. Policies are not watched: recheck periodically
+	if violated, err := r.checkPolicies(ctx, instance); violated || err != nil {
+		return reconcile.Result{RequeueAfter: policyRecheckInterval}, err
+	}
+
 	// Sync metric definitions from the config map
 	metricsSycned := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionMetricsSynced)
 	if metricsSycned == nil || metricsSycned.Status != corev1.ConditionTrue {
 		if err := readMetrics(ctx, r, instance); err != nil {
 			r.MarkSyncMetricsError(ctx, instance, "Fail to read metrics: %v", err)
-			return reconcile.Result{}, r.Status().Update(ctx, instance)
+			return reconcile.Result{}, nil
 		}
 		r.MarkSyncMetrics(ctx, instance)
 	}
 
-	apiVersion := instance.Spec.TargetService.APIVersion
+	router, err := r.newRouter(instance)
+	if err != nil {
+		r.MarkTargetsError(ctx, instance, "%v", err)
+		return reconcile.Result{}, nil
+	}
 
-	switch apiVersion {
-	case KubernetesService:
-		return r.syncKubernetes(ctx, instance)
-	case KnativeServiceV1Alpha1:
-		return r.syncKnative(ctx, instance)
-	default:
-		instance.Status.MarkTargetsError("UnsupportedAPIVersion", "%s", apiVersion)
-		err := r.Status().Update(ctx, instance)
-		return reconcile.Result{}, err
+	// A dry run never changes the routing or the targets
+	if instance.Spec.DryRun {
+		return r.syncDryRun(ctx, instance, router)
 	}
+
+	return r.syncRouting(ctx, instance, router)
 }
 
 func (r *ExperimentReconciler) finalize(context context.Context, instance *iter8v1alpha1.Experiment) (reconcile.Result, error) {
 	log := Logger(context)
 	log.Info("finalizing")
 
-	apiVersion := instance.Spec.TargetService.APIVersion
-	switch apiVersion {
-	case KubernetesService:
-		return r.finalizeIstio(context, instance)
-	case KnativeServiceV1Alpha1:
-		return r.finalizeKnative(context, instance)
+	// A dry run has nothing to roll back
+	if instance.Spec.DryRun {
+		return reconcile.Result{}, removeFinalizer(context, r, instance, Finalizer)
+	}
+
+	if router, err := r.newRouter(instance); err == nil {
+		if err := router.Finalize(context, instance); err != nil {
+			return reconcile.Result{}, err
+		}
 	}
 
 	return reconcile.Result{}, removeFinalizer(context, r, instance, Finalizer)
@@ -257,8 +317,14 @@ func Logger(ctx context.Context) logr.Logger {
 }
 
 func (r *ExperimentReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	c, err := ctrl.NewControllerManagedBy(mgr).
 		For(&iter8v1alpha1.Experiment{}).
-		Complete(r)
+		WithEventFilter(experimentChangedPredicate).
+		WithOptions(controller.Options{MaxConcurrentReconciles: r.MaxConcurrentReconciles}).
+		Build(r)
+	if err != nil {
+		return err
+	}
+	return r.watchTargets(mgr, c)
 }
 
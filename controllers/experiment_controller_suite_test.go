@@ -16,6 +16,7 @@ limitations under the License.
 package experiment
 
 import (
+	"context"
 	stdlog "log"
 	"os"
 	"path/filepath"
@@ -23,10 +24,23 @@ import (
 	"testing"
 
 	"github.com/iter8-tools/iter8-controller/pkg/apis"
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	"github.com/onsi/gomega"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiofake "istio.io/client-go/pkg/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
@@ -72,3 +86,217 @@ func StartTestManager(mgr manager.Manager, g *gomega.GomegaWithT) (chan struct{}
 	}()
 	return stop, wg
 }
+
+// newFakeReconciler returns a reconciler whose clients are fakes holding objs.
+// Istio routing rules go to the fake Istio clientset, other objects to the fake client
+func newFakeReconciler(g *gomega.GomegaWithT, objs ...runtime.Object) *ExperimentReconciler {
+	s := runtime.NewScheme()
+	g.Expect(scheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(apis.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(servingv1alpha1.AddToScheme(s)).To(gomega.Succeed())
+	// routing objects handled as unstructured content
+	for _, gvk := range []schema.GroupVersionKind{TrafficSplitGVK, HTTPRouteGVK} {
+		s.AddKnownTypeWithName(gvk, &unstructured.Unstructured{})
+		s.AddKnownTypeWithName(gvk.GroupVersion().WithKind(gvk.Kind+"List"), &unstructured.UnstructuredList{})
+	}
+
+	var istioObjs, clientObjs []runtime.Object
+	for _, obj := range objs {
+		switch obj.(type) {
+		case *v1alpha3.DestinationRule, *v1alpha3.VirtualService:
+			istioObjs = append(istioObjs, obj)
+		default:
+			clientObjs = append(clientObjs, obj)
+		}
+	}
+	return &ExperimentReconciler{
+		Client:        fake.NewFakeClientWithScheme(s, clientObjs...),
+		scheme:        s,
+		eventRecorder: record.NewFakeRecorder(100),
+		istioClient:   istiofake.NewSimpleClientset(istioObjs...),
+	}
+}
+
+// newTestContext returns a context holding the logger of the reconciles
+func newTestContext() context.Context {
+	return context.WithValue(context.Background(), loggerKey, logf.Log.WithName("test"))
+}
+
+// newTestServices returns the services of the given names
+func newTestServices(namespace string, names ...string) []runtime.Object {
+	services := make([]runtime.Object, len(names))
+	for i, name := range names {
+		services[i] = &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+	}
+	return services
+}
+
+// getTestObject fetches the unstructured object of the given kind and name from the fake client of r
+func getTestObject(g *gomega.GomegaWithT, r *ExperimentReconciler, gvk schema.GroupVersionKind,
+	name types.NamespacedName) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+	g.Expect(r.Get(newTestContext(), name, obj)).To(gomega.Succeed())
+	return obj
+}
+
+// routerTestCase is a router whose routing object splits the traffic of service reviews between services
+// in namespace bookinfo. Its experiment reviews-v2-rollout moves the traffic from reviews-v1 to reviews-v2
+type routerTestCase struct {
+	provider      string
+	newExperiment func() *iter8v1alpha1.Experiment
+	// newObjects returns the target services, and a routing object which sends traffic to reviews-v1 with weight 90
+	// and to reviews-v0 with weight 10 if existing is set
+	newObjects func(g *gomega.GomegaWithT, existing bool) []runtime.Object
+	// getRouting fetches the routing object of the experiment, nil if there is none
+	getRouting func(g *gomega.GomegaWithT, r *ExperimentReconciler, instance *iter8v1alpha1.Experiment) *unstructured.Unstructured
+	// weights returns the weight of each service the routing object sends the traffic of the experiment to
+	weights func(routing *unstructured.Unstructured, instance *iter8v1alpha1.Experiment) map[string]int
+}
+
+var routerTestCases = []routerTestCase{
+	{
+		provider:      iter8v1alpha1.RoutingProviderSMI,
+		newExperiment: newSMITestExperiment,
+		newObjects: func(g *gomega.GomegaWithT, existing bool) []runtime.Object {
+			if !existing {
+				return newSMITestObjects(g)
+			}
+			return newSMITestObjects(g, TrafficSplitBackend{Service: "reviews-v1", Weight: 90},
+				TrafficSplitBackend{Service: "reviews-v0", Weight: 10})
+		},
+		getRouting: func(g *gomega.GomegaWithT, r *ExperimentReconciler, instance *iter8v1alpha1.Experiment) *unstructured.Unstructured {
+			split := &SMITrafficSplit{}
+			g.Expect(split.GetTrafficSplit(newTestContext(), instance, r.Client)).To(gomega.Succeed())
+			return split.TrafficSplit
+		},
+		weights: func(routing *unstructured.Unstructured, instance *iter8v1alpha1.Experiment) map[string]int {
+			backends, _ := getBackends(routing)
+			weights := map[string]int{}
+			for _, backend := range backends {
+				weights[backend.Service] = backend.Weight
+			}
+			return weights
+		},
+	},
+	{
+		provider:      iter8v1alpha1.RoutingProviderGateway,
+		newExperiment: newGatewayTestExperiment,
+		newObjects: func(g *gomega.GomegaWithT, existing bool) []runtime.Object {
+			if !existing {
+				return newGatewayTestObjects(g)
+			}
+			return newGatewayTestObjects(g, map[string]interface{}{"backendRefs": []interface{}{
+				map[string]interface{}{"name": "reviews-v1", "port": int64(9080), "weight": int64(90)},
+				map[string]interface{}{"name": "reviews-v0", "port": int64(9080), "weight": int64(10)},
+			}})
+		},
+		getRouting: func(g *gomega.GomegaWithT, r *ExperimentReconciler, instance *iter8v1alpha1.Experiment) *unstructured.Unstructured {
+			route := &GatewayHTTPRoute{}
+			g.Expect(route.GetHTTPRoute(newTestContext(), instance, r.Client)).To(gomega.Succeed())
+			return route.Route
+		},
+		weights: func(routing *unstructured.Unstructured, instance *iter8v1alpha1.Experiment) map[string]int {
+			route := &GatewayHTTPRoute{Route: routing}
+			weights := map[string]int{}
+			for _, rule := range routeRules(routing) {
+				for _, ref := range backendRefs(rule) {
+					name, _ := ref["name"].(string)
+					weights[name] = route.GetWeight(instance, name)
+				}
+			}
+			return weights
+		},
+	},
+}
+
+// TestRouterLifecycle runs the experiment through each router, on a routing object created by the experiment
+// and on one which existed before it
+func TestRouterLifecycle(t *testing.T) {
+	for _, tc := range routerTestCases {
+		t.Run(tc.provider, func(t *testing.T) {
+			g := gomega.NewGomegaWithT(t)
+			ctx := newTestContext()
+			factory := routers[KubernetesService][tc.provider]
+
+			// the routing object is created when the target service has none
+			instance := tc.newExperiment()
+			r := newFakeReconciler(g, tc.newObjects(g, false)...)
+			g.Expect(factory(r).Init(ctx, instance)).To(gomega.Succeed())
+			routing := tc.getRouting(g, r, instance)
+			g.Expect(routing.GetLabels()).To(gomega.HaveKeyWithValue(experimentInit, "True"))
+			g.Expect(routing.GetLabels()).To(gomega.HaveKeyWithValue(experimentLabel, instance.Name))
+			g.Expect(tc.weights(routing, instance)).To(gomega.Equal(map[string]int{"reviews-v1": 100, "reviews-v2": 0}))
+			g.Expect(instance.Status.RoutingSnapshot).To(gomega.BeNil())
+
+			// each reconcile finds the routing object again
+			router := factory(r)
+			g.Expect(router.Init(ctx, instance)).To(gomega.Succeed())
+			g.Expect(router.SetWeights(ctx, instance, 70, []int{30})).To(gomega.Succeed())
+			router = factory(r)
+			g.Expect(router.Init(ctx, instance)).To(gomega.Succeed())
+			baseline, candidates := router.GetWeights(instance)
+			g.Expect(baseline).To(gomega.Equal(70))
+			g.Expect(candidates).To(gomega.Equal([]int{30}))
+
+			// the winner gets all the traffic and the routing object is released
+			instance.Status.Winner = "reviews-v2"
+			g.Expect(router.Promote(ctx, instance)).To(gomega.Succeed())
+			routing = tc.getRouting(g, r, instance)
+			g.Expect(tc.weights(routing, instance)).To(gomega.Equal(map[string]int{"reviews-v2": 100}))
+			g.Expect(routing.GetLabels()).To(gomega.HaveKeyWithValue(experimentRole, Stable))
+			g.Expect(routing.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+			g.Expect(routing.GetLabels()).NotTo(gomega.HaveKey(experimentInit))
+
+			// an existing routing object is snapshotted, and taken over only once the snapshot is persisted
+			existing := map[string]int{"reviews-v1": 90, "reviews-v0": 10}
+			takeOver := func() (*ExperimentReconciler, *iter8v1alpha1.Experiment, Router) {
+				instance := tc.newExperiment()
+				r := newFakeReconciler(g, tc.newObjects(g, true)...)
+				g.Expect(factory(r).Init(ctx, instance)).To(gomega.Equal(errSnapshotTaken))
+				g.Expect(instance.Status.RoutingSnapshot).NotTo(gomega.BeNil())
+				routing := tc.getRouting(g, r, instance)
+				g.Expect(routing.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+				g.Expect(tc.weights(routing, instance)).To(gomega.Equal(existing))
+
+				router := factory(r)
+				g.Expect(router.Init(ctx, instance)).To(gomega.Succeed())
+				routing = tc.getRouting(g, r, instance)
+				g.Expect(routing.GetLabels()).To(gomega.HaveKeyWithValue(experimentLabel, instance.Name))
+				g.Expect(routing.GetLabels()).NotTo(gomega.HaveKey(experimentInit))
+
+				// the traffic sent to other services is left alone
+				g.Expect(tc.weights(routing, instance)).To(gomega.Equal(
+					map[string]int{"reviews-v0": 10, "reviews-v1": 100, "reviews-v2": 0}))
+				g.Expect(router.SetWeights(ctx, instance, 50, []int{50})).To(gomega.Succeed())
+				g.Expect(tc.weights(tc.getRouting(g, r, instance), instance)).To(gomega.Equal(
+					map[string]int{"reviews-v0": 10, "reviews-v1": 50, "reviews-v2": 50}))
+				return r, instance, router
+			}
+
+			// a failed experiment restores the routing object as it was
+			r, instance, router = takeOver()
+			instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+			g.Expect(router.Rollback(ctx, instance)).To(gomega.Succeed())
+			routing = tc.getRouting(g, r, instance)
+			g.Expect(tc.weights(routing, instance)).To(gomega.Equal(existing))
+			g.Expect(routing.GetLabels()).To(gomega.HaveKeyWithValue(experimentRole, Stable))
+			g.Expect(routing.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+
+			// a successful one restores it with the winner in place of the baseline
+			r, instance, router = takeOver()
+			instance.Status.Winner = "reviews-v2"
+			g.Expect(router.Promote(ctx, instance)).To(gomega.Succeed())
+			routing = tc.getRouting(g, r, instance)
+			g.Expect(tc.weights(routing, instance)).To(gomega.Equal(map[string]int{"reviews-v2": 90, "reviews-v0": 10}))
+			g.Expect(routing.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+
+			// an experiment deleted before completion hands the routing object back as it was
+			r, instance, _ = takeOver()
+			g.Expect(factory(r).Finalize(ctx, instance)).To(gomega.Succeed())
+			routing = tc.getRouting(g, r, instance)
+			g.Expect(tc.weights(routing, instance)).To(gomega.Equal(existing))
+			g.Expect(routing.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+const (
+	// analyticsRetryInterval is the time before the first retry of a failed analysis, doubled by each failure
+	analyticsRetryInterval = 5 * time.Second
+
+	// maxAnalyticsRetryInterval caps the time between retries of a failed analysis
+	maxAnalyticsRetryInterval = 5 * time.Minute
+)
+
+// checkFailureBudget returns the action of the failure policy of the experiment once the analytics service
+// failed beyond its limits, or "" while the failures are tolerated. The action is reported once.
+func (r *ExperimentReconciler) checkFailureBudget(context context.Context, instance *iter8v1alpha1.Experiment) string {
+	status := &instance.Status
+	outage := time.Duration(0)
+	if status.AnalyticsFailingSince != nil {
+		outage = time.Since(status.AnalyticsFailingSince.Time)
+	}
+
+	policy := instance.Spec.Analysis.FailurePolicy
+	if !policy.IsExhausted(status.AnalyticsFailures, outage) {
+		return ""
+	}
+
+	action := policy.GetAction()
+	if status.FailurePolicyAction != action {
+		status.FailurePolicyAction = action
+		r.recordFailureBudgetExhausted(context, instance, "%d consecutive analytics failures over %s, action: %s",
+			status.AnalyticsFailures, outage.Round(time.Second), action)
+	}
+	return action
+}
+
+// analyticsRetry returns when to reconcile the experiment again after a failed analysis:
+// with exponential backoff, or right away once the analytics service is no longer called
+func analyticsRetry(instance *iter8v1alpha1.Experiment) reconcile.Result {
+	if instance.Status.FailurePolicyAction == iter8v1alpha1.FailureActionIncrementWithoutCheck {
+		return reconcile.Result{Requeue: true}
+	}
+	return reconcile.Result{RequeueAfter: analyticsBackoff(instance.Status.AnalyticsFailures)}
+}
+
+// analyticsRetryIn returns the time left at now before a failed analysis may be retried, so that retries are held
+// until their backoff is over. The last record of the history is the one of the failed analysis.
+func analyticsRetryIn(instance *iter8v1alpha1.Experiment, now time.Time) time.Duration {
+	status := instance.Status
+	if status.AnalyticsFailures == 0 || status.FailurePolicyAction == iter8v1alpha1.FailureActionIncrementWithoutCheck ||
+		len(status.History) == 0 {
+		return 0
+	}
+	failedAt := status.History[len(status.History)-1].Timestamp
+	return failedAt.Add(analyticsBackoff(status.AnalyticsFailures)).Sub(now)
+}
+
+// analyticsBackoff returns the time before retrying the analysis after failures consecutive failures
+func analyticsBackoff(failures int) time.Duration {
+	backoff := analyticsRetryInterval
+	for i := 1; i < failures && backoff < maxAnalyticsRetryInterval; i++ {
+		backoff *= 2
+	}
+	if backoff > maxAnalyticsRetryInterval {
+		backoff = maxAnalyticsRetryInterval
+	}
+	return backoff
+}
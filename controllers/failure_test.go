@@ -0,0 +1,157 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAnalyticsBackoff(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(analyticsBackoff(1)).To(gomega.Equal(analyticsRetryInterval))
+	g.Expect(analyticsBackoff(3)).To(gomega.Equal(4 * analyticsRetryInterval))
+	g.Expect(analyticsBackoff(100)).To(gomega.Equal(maxAnalyticsRetryInterval))
+
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Status.InitializeConditions()
+	instance.Status.MarkAnalyticsServiceError("AnalyticsServiceError", "")
+	instance.Status.MarkAnalyticsServiceError("AnalyticsServiceError", "")
+	g.Expect(instance.Status.AnalyticsFailures).To(gomega.Equal(2))
+	g.Expect(instance.Status.AnalyticsFailingSince).NotTo(gomega.BeNil())
+	g.Expect(analyticsRetry(instance).RequeueAfter).To(gomega.Equal(2 * analyticsRetryInterval))
+	recordIteration(instance, errors.New("analytics service unavailable"))
+	g.Expect(analyticsRetryIn(instance, time.Now())).To(gomega.BeNumerically(">", analyticsRetryInterval))
+	g.Expect(analyticsRetryIn(instance, time.Now().Add(maxAnalyticsRetryInterval))).To(gomega.BeNumerically("<", 0))
+
+	instance.Status.FailurePolicyAction = iter8v1alpha1.FailureActionIncrementWithoutCheck
+	g.Expect(analyticsRetry(instance).Requeue).To(gomega.BeTrue())
+
+	instance.Status.FailurePolicyAction = iter8v1alpha1.FailureActionHold
+	instance.Status.MarkAnalyticsServiceRunning()
+	g.Expect(instance.Status.AnalyticsFailures).To(gomega.BeZero())
+	g.Expect(instance.Status.AnalyticsFailingSince).To(gomega.BeNil())
+	g.Expect(instance.Status.FailurePolicyAction).To(gomega.BeEmpty())
+}
+
+func TestFailurePolicy(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var policy *iter8v1alpha1.FailurePolicy
+	g.Expect(policy.IsExhausted(1000, time.Hour)).To(gomega.BeFalse())
+	g.Expect(policy.GetAction()).To(gomega.Equal(iter8v1alpha1.FailureActionHold))
+
+	maxFailures, maxOutage, action := 3, "10m", iter8v1alpha1.FailureActionRollback
+	policy = &iter8v1alpha1.FailurePolicy{MaxConsecutiveFailures: &maxFailures, MaxOutageDuration: &maxOutage, Action: &action}
+	g.Expect(policy.IsExhausted(2, time.Minute)).To(gomega.BeFalse())
+	g.Expect(policy.IsExhausted(3, time.Minute)).To(gomega.BeTrue())
+	g.Expect(policy.IsExhausted(1, 10*time.Minute)).To(gomega.BeTrue())
+	g.Expect(policy.GetAction()).To(gomega.Equal(iter8v1alpha1.FailureActionRollback))
+}
+
+// stubRouter keeps the traffic split in memory and counts its updates
+type stubRouter struct {
+	baseline   int
+	candidates []int
+	updates    int
+}
+
+func (s *stubRouter) Init(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return nil
+}
+
+func (s *stubRouter) Targets(context context.Context, instance *iter8v1alpha1.Experiment) (interface{}, []interface{}, error) {
+	return nil, make([]interface{}, len(s.candidates)), nil
+}
+
+func (s *stubRouter) Completed(instance *iter8v1alpha1.Experiment) bool {
+	return experimentCompleted(instance)
+}
+
+func (s *stubRouter) GetWeights(instance *iter8v1alpha1.Experiment) (int, []int) {
+	return s.baseline, append([]int{}, s.candidates...)
+}
+
+func (s *stubRouter) SetWeights(context context.Context, instance *iter8v1alpha1.Experiment, baseline int, candidates []int) error {
+	s.baseline, s.candidates = baseline, append([]int{}, candidates...)
+	s.updates++
+	return nil
+}
+
+func (s *stubRouter) Promote(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return nil
+}
+
+func (s *stubRouter) Rollback(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return nil
+}
+
+func (s *stubRouter) Finalize(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return nil
+}
+
+func TestFailurePolicyFallback(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	r := newFakeReconciler(g)
+	ctx := newTestContext()
+
+	strategy, interval := iter8v1alpha1.StrategyCheckAndIncrement, "1m"
+	instance := &iter8v1alpha1.Experiment{ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2-rollout", Namespace: "bookinfo"}}
+	instance.Spec.TargetService = iter8v1alpha1.TargetService{
+		ObjectReference: &corev1.ObjectReference{APIVersion: KubernetesService, Name: "reviews"},
+		Baseline:        "reviews-v1",
+		Candidate:       "reviews-v2",
+	}
+	instance.Spec.TrafficControl = iter8v1alpha1.TrafficControl{Strategy: &strategy, Interval: &interval}
+	instance.Spec.Analysis.SuccessCriteria = []iter8v1alpha1.SuccessCriterion{
+		{MetricName: "iter8_latency", ToleranceType: iter8v1alpha1.ToleranceTypeThreshold, Tolerance: 0.2},
+	}
+	instance.Status.InitializeConditions()
+	instance.Status.LastIncrementTime = metav1.NewTime(time.Unix(0, 0))
+	setTrafficSplit(instance, 100, []int{0})
+
+	// the analytics service is given up on: the traffic goes on increasing without it
+	instance.Status.MarkAnalyticsServiceError("AnalyticsServiceError", "")
+	recordIteration(instance, errors.New("analytics service unavailable"))
+	instance.Status.FailurePolicyAction = iter8v1alpha1.FailureActionIncrementWithoutCheck
+	router := &stubRouter{baseline: 100, candidates: []int{0}}
+
+	result, err := r.syncRouting(ctx, instance, router)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.RequeueAfter).To(gomega.Equal(time.Minute))
+	g.Expect(router.updates).To(gomega.Equal(1))
+	g.Expect(instance.Status.CurrentIteration).To(gomega.Equal(1))
+
+	// the next iteration still waits for the interval
+	result, err = r.syncRouting(ctx, instance, router)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.RequeueAfter).To(gomega.BeNumerically(">", 0))
+	g.Expect(router.updates).To(gomega.Equal(1))
+	g.Expect(instance.Status.CurrentIteration).To(gomega.Equal(1))
+
+	// the candidate was not assessed: it is only promoted by the user
+	g.Expect(experimentSucceeded(instance)).To(gomega.BeFalse())
+	instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideSuccess
+	g.Expect(experimentSucceeded(instance)).To(gomega.BeTrue())
+}
@@ -0,0 +1,522 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// HTTPRouteGVK is the Gateway API HTTPRoute driven by the gateway routing provider.
+// It is handled as unstructured content so that no Gateway API client is needed
+var HTTPRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1beta1", Kind: "HTTPRoute"}
+
+// GatewayHTTPRoute is the HTTPRoute named by the routing reference of the experiment.
+// The experiment drives the backendRefs of the rules routing to its baseline or candidate services
+type GatewayHTTPRoute struct {
+	Route *unstructured.Unstructured
+}
+
+// httpRouteKey returns the name and namespace of the HTTPRoute of the experiment
+func httpRouteKey(instance *iter8v1alpha1.Experiment) types.NamespacedName {
+	key := types.NamespacedName{Name: instance.GetName(), Namespace: getServiceNamespace(instance)}
+	if ref := instance.Spec.RoutingReference; ref != nil {
+		if ref.Name != "" {
+			key.Name = ref.Name
+		}
+		if ref.Namespace != "" {
+			key.Namespace = ref.Namespace
+		}
+	}
+	return key
+}
+
+// GetHTTPRoute fetches the HTTPRoute of the experiment. The route is left empty if it does not exist
+func (h *GatewayHTTPRoute) GetHTTPRoute(context context.Context, instance *iter8v1alpha1.Experiment, c client.Client) error {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	if err := c.Get(context, httpRouteKey(instance), route); err != nil {
+		return ignoreNotFound(err)
+	}
+	if exp, ok := route.GetLabels()[experimentLabel]; ok && exp != instance.GetName() {
+		return fmt.Errorf("HTTPRoute %s is controlled by experiment %s", route.GetName(), exp)
+	}
+	h.Route = route
+	return nil
+}
+
+// InitHTTPRoute creates the HTTPRoute of the experiment when it does not exist. The route is attached to the
+// target service, and sends all the traffic to the baseline service on the first port of the target service
+func (h *GatewayHTTPRoute) InitHTTPRoute(context context.Context, instance *iter8v1alpha1.Experiment, services *ServiceTargets,
+	c client.Client) error {
+	key := httpRouteKey(instance)
+	serviceNamespace := getServiceNamespace(instance)
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	route.SetName(key.Name)
+	route.SetNamespace(key.Namespace)
+	route.SetLabels(map[string]string{
+		experimentRole:  Progressing,
+		experimentInit:  "True",
+		experimentLabel: instance.GetName(),
+		experimentHost:  instance.Spec.TargetService.Name,
+	})
+
+	parentRef := map[string]interface{}{
+		"group":     "",
+		"kind":      "Service",
+		"name":      instance.Spec.TargetService.Name,
+		"namespace": serviceNamespace,
+	}
+	backendRef := map[string]interface{}{
+		"group": "",
+		"kind":  "Service",
+		"name":  instance.Spec.TargetService.Baseline,
+	}
+	if key.Namespace != serviceNamespace {
+		backendRef["namespace"] = serviceNamespace
+	}
+	if ports := services.Service.Spec.Ports; len(ports) > 0 {
+		backendRef["port"] = int64(ports[0].Port)
+	}
+	if err := unstructured.SetNestedSlice(route.Object, []interface{}{parentRef}, "spec", "parentRefs"); err != nil {
+		return err
+	}
+	rule := map[string]interface{}{"backendRefs": []interface{}{backendRef}}
+	if err := unstructured.SetNestedSlice(route.Object, []interface{}{rule}, "spec", "rules"); err != nil {
+		return err
+	}
+	if err := setTargetRefs(route, instance, experimentServices(instance), initialWeights(instance)); err != nil {
+		return err
+	}
+
+	if err := c.Create(context, route); err != nil {
+		return err
+	}
+	h.Route = route
+	return nil
+}
+
+func (h *GatewayHTTPRoute) IsEmpty() bool {
+	return h.Route == nil
+}
+
+// IsStable tells whether the HTTPRoute is not controlled by any experiment
+func (h *GatewayHTTPRoute) IsStable() bool {
+	_, ok := h.Route.GetLabels()[experimentLabel]
+	return !ok
+}
+
+// IsProgressing tells whether the HTTPRoute is registered with experiment expName
+func (h *GatewayHTTPRoute) IsProgressing(expName string) bool {
+	labels := h.Route.GetLabels()
+	return labels[experimentRole] == Progressing && labels[experimentLabel] == expName
+}
+
+// IsInit tells whether the HTTPRoute was created by the experiment
+func (h *GatewayHTTPRoute) IsInit() bool {
+	_, ok := h.Route.GetLabels()[experimentInit]
+	return ok
+}
+
+// StableToProgressing registers the HTTPRoute with the experiment and sends all the traffic of its rules
+// routing to the baseline or a candidate to the baseline
+func (h *GatewayHTTPRoute) StableToProgressing(context context.Context, instance *iter8v1alpha1.Experiment, c client.Client) error {
+	route := h.Route.DeepCopy()
+	labels := route.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[experimentRole] = Progressing
+	labels[experimentLabel] = instance.GetName()
+	labels[experimentHost] = instance.Spec.TargetService.Name
+	route.SetLabels(labels)
+	if err := setTargetRefs(route, instance, experimentServices(instance), initialWeights(instance)); err != nil {
+		return err
+	}
+	return h.update(context, route, c)
+}
+
+// GetWeight returns the weight of the backend service in the first rule routing to the experiment, 0 if it is not a backend
+func (h *GatewayHTTPRoute) GetWeight(instance *iter8v1alpha1.Experiment, service string) int {
+	rules, _, _ := unstructured.NestedSlice(h.Route.Object, "spec", "rules")
+	targets := targetNames(instance)
+	for _, rule := range rules {
+		refs := backendRefs(rule)
+		if !hasTargetRef(refs, targets) {
+			continue
+		}
+		for _, ref := range refs {
+			if isServiceRef(ref) && ref["name"] == service {
+				weight, found, _ := unstructured.NestedInt64(ref, "weight")
+				if !found {
+					// the Gateway API default
+					return 1
+				}
+				return int(weight)
+			}
+		}
+		return 0
+	}
+	return 0
+}
+
+// UpdateWeights routes the given traffic percentages to the baseline and to each candidate service
+func (h *GatewayHTTPRoute) UpdateWeights(context context.Context, instance *iter8v1alpha1.Experiment, baseline int, candidates []int,
+	c client.Client) error {
+	route := h.Route.DeepCopy()
+	if err := setTargetRefs(route, instance, experimentServices(instance), append([]int{baseline}, candidates...)); err != nil {
+		return err
+	}
+	return h.update(context, route, c)
+}
+
+// Cleanup settles the HTTPRoute at the end of the experiment and releases it. The original backendRefs are restored,
+// with the winner in place of the baseline when the candidate is promoted.
+// An HTTPRoute created by the experiment is removed when the experiment asks to clean up
+func (h *GatewayHTTPRoute) Cleanup(context context.Context, instance *iter8v1alpha1.Experiment, c client.Client) error {
+	if instance.Spec.CleanUp == iter8v1alpha1.CleanUpDelete && h.IsInit() {
+		return ignoreNotFound(c.Delete(context, h.Route))
+	}
+
+	route := h.Route.DeepCopy()
+	baseline, stable := instance.Spec.TargetService.Baseline, instance.Spec.TargetService.Baseline
+	succeeded := experimentSucceeded(instance)
+	if succeeded && instance.Spec.TrafficControl.GetOnSuccess() == "candidate" {
+		for _, candidate := range instance.Spec.TargetService.GetCandidates() {
+			if candidate == instance.Status.Winner {
+				stable = candidate
+			}
+		}
+	}
+
+	if succeeded && instance.Spec.TrafficControl.GetOnSuccess() == "both" {
+		// the traffic stays split as it is
+	} else if snapshot := instance.Status.RoutingSnapshot; snapshot != nil {
+		if err := restoreRules(snapshot, route); err != nil {
+			return err
+		}
+		if stable != baseline {
+			if err := renameServiceRefs(route, baseline, stable); err != nil {
+				return err
+			}
+		}
+	} else if err := setTargetRefs(route, instance, []string{stable}, []int{100}); err != nil {
+		return err
+	}
+
+	labels := route.GetLabels()
+	delete(labels, experimentLabel)
+	delete(labels, experimentInit)
+	labels[experimentRole] = Stable
+	route.SetLabels(labels)
+	return h.update(context, route, c)
+}
+
+// Snapshot returns the rules of the HTTPRoute, to be restored by Cleanup
+func (h *GatewayHTTPRoute) Snapshot() (*iter8v1alpha1.RoutingSnapshot, error) {
+	rules, _, err := unstructured.NestedSlice(h.Route.Object, "spec", "rules")
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+	return &iter8v1alpha1.RoutingSnapshot{Rules: &runtime.RawExtension{Raw: raw}}, nil
+}
+
+// update writes the HTTPRoute if it changed
+func (h *GatewayHTTPRoute) update(context context.Context, route *unstructured.Unstructured, c client.Client) error {
+	if equality.Semantic.DeepEqual(route.Object, h.Route.Object) {
+		return nil
+	}
+	if err := c.Update(context, route); err != nil {
+		return err
+	}
+	h.Route = route
+	return nil
+}
+
+// experimentServices returns the baseline and the candidates of the experiment
+func experimentServices(instance *iter8v1alpha1.Experiment) []string {
+	return append([]string{instance.Spec.TargetService.Baseline}, instance.Spec.TargetService.GetCandidates()...)
+}
+
+// initialWeights sends all the traffic to the baseline
+func initialWeights(instance *iter8v1alpha1.Experiment) []int {
+	weights := make([]int, len(experimentServices(instance)))
+	weights[0] = 100
+	return weights
+}
+
+func targetNames(instance *iter8v1alpha1.Experiment) map[string]bool {
+	names := map[string]bool{}
+	for _, name := range experimentServices(instance) {
+		names[name] = true
+	}
+	return names
+}
+
+// backendRefs returns the backendRefs of an HTTPRoute rule
+func backendRefs(rule interface{}) []map[string]interface{} {
+	r, ok := rule.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	refs, _ := r["backendRefs"].([]interface{})
+	out := make([]map[string]interface{}, 0, len(refs))
+	for _, ref := range refs {
+		if m, ok := ref.(map[string]interface{}); ok {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// isServiceRef tells whether the backendRef refers to a core service, the Gateway API default
+func isServiceRef(ref map[string]interface{}) bool {
+	group, _, _ := unstructured.NestedString(ref, "group")
+	kind, _, _ := unstructured.NestedString(ref, "kind")
+	return group == "" && (kind == "" || kind == "Service")
+}
+
+func hasTargetRef(refs []map[string]interface{}, targets map[string]bool) bool {
+	for _, ref := range refs {
+		if name, _ := ref["name"].(string); isServiceRef(ref) && targets[name] {
+			return true
+		}
+	}
+	return false
+}
+
+// setTargetRefs rewrites the backendRefs of the rules routing to the baseline or a candidate so that they route
+// to the given services with the given weights. The refs are modelled on the first target ref of each rule,
+// and the other backends of the rule are left as they are
+func setTargetRefs(route *unstructured.Unstructured, instance *iter8v1alpha1.Experiment, services []string, weights []int) error {
+	rules, _, err := unstructured.NestedSlice(route.Object, "spec", "rules")
+	if err != nil {
+		return err
+	}
+
+	targets := targetNames(instance)
+	found := false
+	for _, rule := range rules {
+		refs := backendRefs(rule)
+		if !hasTargetRef(refs, targets) {
+			continue
+		}
+		found = true
+
+		var template map[string]interface{}
+		out := []interface{}{}
+		for _, ref := range refs {
+			if name, _ := ref["name"].(string); isServiceRef(ref) && targets[name] {
+				if template == nil {
+					template = ref
+				}
+				continue
+			}
+			out = append(out, ref)
+		}
+		for i, service := range services {
+			ref := runtime.DeepCopyJSONValue(template).(map[string]interface{})
+			ref["name"] = service
+			ref["weight"] = int64(weights[i])
+			out = append(out, ref)
+		}
+		rule.(map[string]interface{})["backendRefs"] = out
+	}
+
+	if !found {
+		return fmt.Errorf("HTTPRoute %s has no rule routing to %s", route.GetName(), instance.Spec.TargetService.Baseline)
+	}
+	return unstructured.SetNestedSlice(route.Object, rules, "spec", "rules")
+}
+
+// renameServiceRefs makes the backendRefs to service from refer to service to instead
+func renameServiceRefs(route *unstructured.Unstructured, from, to string) error {
+	rules, _, err := unstructured.NestedSlice(route.Object, "spec", "rules")
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		for _, ref := range backendRefs(rule) {
+			if isServiceRef(ref) && ref["name"] == from {
+				ref["name"] = to
+			}
+		}
+	}
+	return unstructured.SetNestedSlice(route.Object, rules, "spec", "rules")
+}
+
+// restoreRules sets the rules of the HTTPRoute back to the snapshot
+func restoreRules(snapshot *iter8v1alpha1.RoutingSnapshot, route *unstructured.Unstructured) error {
+	if snapshot.Rules == nil {
+		return fmt.Errorf("RoutingSnapshotWithoutRules")
+	}
+	rules := []interface{}{}
+	if err := json.Unmarshal(snapshot.Rules.Raw, &rules); err != nil {
+		return err
+	}
+	return unstructured.SetNestedSlice(route.Object, rules, "spec", "rules")
+}
+
+func init() {
+	registerRouter(KubernetesService, iter8v1alpha1.RoutingProviderGateway, newGatewayRouter)
+}
+
+// gatewayRouter routes the traffic of a Kubernetes service between the baseline and candidate services
+// with the backendRefs of a Gateway API HTTPRoute
+type gatewayRouter struct {
+	r *ExperimentReconciler
+
+	route    *GatewayHTTPRoute
+	services *ServiceTargets
+}
+
+func newGatewayRouter(r *ExperimentReconciler) Router {
+	return &gatewayRouter{r: r, route: &GatewayHTTPRoute{}}
+}
+
+// Init implements Router
+func (g *gatewayRouter) Init(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	if err := g.route.GetHTTPRoute(context, instance, g.r.Client); err != nil {
+		g.r.MarkRoutingRulesError(context, instance, "Error in getting HTTPRoute: %v", err)
+		return err
+	}
+
+	// A new HTTPRoute routes on the port of the target service, so the services are resolved first
+	if g.services == nil {
+		services, err := g.r.getServiceTargets(context, instance)
+		if err != nil {
+			return err
+		}
+		g.services = services
+	}
+
+	if g.route.IsEmpty() {
+		if err := g.route.InitHTTPRoute(context, instance, g.services, g.r.Client); err != nil {
+			g.r.MarkRoutingRulesError(context, instance, "Error in initializing HTTPRoute: %v", err)
+			return err
+		}
+		g.r.MarkRoutingRulesReady(context, instance, "Init HTTPRoute")
+	} else if g.route.IsStable() {
+		// The rules of an HTTPRoute which existed before the experiment are restored at its end
+		if instance.Status.RoutingSnapshot == nil {
+			snapshot, err := g.route.Snapshot()
+			if err != nil {
+				g.r.MarkRoutingRulesError(context, instance, "Fail to snapshot HTTPRoute: %v", err)
+				return err
+			}
+			instance.Status.RoutingSnapshot = snapshot
+			return errSnapshotTaken
+		}
+		if err := g.route.StableToProgressing(context, instance, g.r.Client); err != nil {
+			g.r.MarkRoutingRulesError(context, instance, "Fail to take over HTTPRoute: %v", err)
+			return err
+		}
+		g.r.MarkRoutingRulesReady(context, instance, "")
+	} else if !g.route.IsProgressing(instance.GetName()) {
+		g.r.MarkRoutingRulesError(context, instance, "HTTPRoute %s is neither stable nor controlled by this experiment",
+			httpRouteKey(instance).Name)
+		return fmt.Errorf("UnexpectedHTTPRoute")
+	} else {
+		g.r.MarkRoutingRulesReady(context, instance, "")
+	}
+
+	g.r.MarkTargetsFound(context, instance)
+	return nil
+}
+
+// Targets implements Router. It returns the baseline and candidate services
+func (g *gatewayRouter) Targets(context context.Context, instance *iter8v1alpha1.Experiment) (interface{}, []interface{}, error) {
+	if g.services == nil {
+		services, err := g.r.getServiceTargets(context, instance)
+		if err != nil {
+			return nil, nil, err
+		}
+		g.services = services
+	}
+	baseline, candidates := g.services.analyticsTargets()
+	return baseline, candidates, nil
+}
+
+// Completed implements Router
+func (g *gatewayRouter) Completed(instance *iter8v1alpha1.Experiment) bool {
+	return experimentCompleted(instance)
+}
+
+// GetWeights implements Router
+func (g *gatewayRouter) GetWeights(instance *iter8v1alpha1.Experiment) (int, []int) {
+	names := instance.Spec.TargetService.GetCandidates()
+	candidates := make([]int, len(names))
+	for i, name := range names {
+		candidates[i] = g.route.GetWeight(instance, name)
+	}
+	return g.route.GetWeight(instance, instance.Spec.TargetService.Baseline), candidates
+}
+
+// SetWeights implements Router
+func (g *gatewayRouter) SetWeights(context context.Context, instance *iter8v1alpha1.Experiment, baseline int, candidates []int) error {
+	return g.route.UpdateWeights(context, instance, baseline, candidates, g.r.Client)
+}
+
+// Promote implements Router
+func (g *gatewayRouter) Promote(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return g.route.Cleanup(context, instance, g.r.Client)
+}
+
+// Rollback implements Router
+func (g *gatewayRouter) Rollback(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return g.route.Cleanup(context, instance, g.r.Client)
+}
+
+// Finalize implements Router. An HTTPRoute created by the experiment is removed, others get their original rules back
+func (g *gatewayRouter) Finalize(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	completed := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionExperimentCompleted)
+	if completed == nil || completed.Status == corev1.ConditionTrue {
+		return nil
+	}
+
+	if err := g.route.GetHTTPRoute(context, instance, g.r.Client); err != nil {
+		return err
+	}
+	// the HTTPRoute may be gone or released already
+	if g.route.IsEmpty() || !g.route.IsProgressing(instance.GetName()) {
+		return nil
+	}
+
+	if g.route.IsInit() {
+		return ignoreNotFound(g.r.Delete(context, g.route.Route))
+	}
+
+	// The original rules are restored as after a failed experiment
+	instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+	return ignoreNotFound(g.route.Cleanup(context, instance, g.r.Client))
+}
@@ -0,0 +1,187 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestHTTPRouteBackendRefs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Spec.TargetService = iter8v1alpha1.TargetService{
+		ObjectReference: &corev1.ObjectReference{APIVersion: KubernetesService, Name: "reviews"},
+		Baseline:        "reviews-v1",
+		Candidate:       "reviews-v2",
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	g.Expect(unstructured.SetNestedSlice(route.Object, []interface{}{
+		map[string]interface{}{"backendRefs": []interface{}{
+			map[string]interface{}{"name": "reviews-v1", "port": int64(9080)},
+			map[string]interface{}{"name": "reviews-legacy", "port": int64(9080), "weight": int64(10)},
+		}},
+		map[string]interface{}{"backendRefs": []interface{}{
+			map[string]interface{}{"name": "ratings", "port": int64(9080)},
+		}},
+	}, "spec", "rules")).To(gomega.Succeed())
+	h := &GatewayHTTPRoute{Route: route}
+	snapshot, err := h.Snapshot()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	// only the rules routing to the experiment are driven, other backends of these rules are left as they are
+	g.Expect(setTargetRefs(route, instance, experimentServices(instance), []int{70, 30})).To(gomega.Succeed())
+	g.Expect(h.GetWeight(instance, "reviews-v1")).To(gomega.Equal(70))
+	g.Expect(h.GetWeight(instance, "reviews-v2")).To(gomega.Equal(30))
+	g.Expect(h.GetWeight(instance, "reviews-legacy")).To(gomega.Equal(10))
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	refs := backendRefs(rules[0])
+	g.Expect(refs).To(gomega.HaveLen(3))
+	g.Expect(refs[0]).To(gomega.Equal(map[string]interface{}{
+		"name": "reviews-legacy", "port": int64(9080), "weight": int64(10),
+	}))
+	g.Expect(refs[2]).To(gomega.HaveKeyWithValue("port", int64(9080)))
+	g.Expect(backendRefs(rules[1])).To(gomega.HaveLen(1))
+
+	// the original rules are restored, with the winner in place of the baseline
+	g.Expect(restoreRules(snapshot, route)).To(gomega.Succeed())
+	g.Expect(renameServiceRefs(route, "reviews-v1", "reviews-v2")).To(gomega.Succeed())
+	rules, _, _ = unstructured.NestedSlice(route.Object, "spec", "rules")
+	refs = backendRefs(rules[0])
+	g.Expect(refs).To(gomega.HaveLen(2))
+	g.Expect(refs[0]).To(gomega.HaveKeyWithValue("name", "reviews-v2"))
+	g.Expect(refs[1]).To(gomega.HaveKeyWithValue("name", "reviews-legacy"))
+
+	// a route must route to the experiment
+	instance.Spec.TargetService.Baseline = "details-v1"
+	instance.Spec.TargetService.Candidate = "details-v2"
+	g.Expect(setTargetRefs(route, instance, experimentServices(instance), []int{100, 0})).NotTo(gomega.Succeed())
+}
+
+// routeRules returns the rules of the HTTPRoute
+func routeRules(route *unstructured.Unstructured) []interface{} {
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	return rules
+}
+
+func newGatewayTestExperiment() *iter8v1alpha1.Experiment {
+	strategy, onSuccess := iter8v1alpha1.StrategyIncrementWithoutCheck, "candidate"
+	instance := &iter8v1alpha1.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2-rollout", Namespace: "bookinfo"},
+		Spec: iter8v1alpha1.ExperimentSpec{
+			TargetService: iter8v1alpha1.TargetService{
+				ObjectReference: &corev1.ObjectReference{APIVersion: KubernetesService, Name: "reviews"},
+				Baseline:        "reviews-v1",
+				Candidate:       "reviews-v2",
+			},
+			TrafficControl:   iter8v1alpha1.TrafficControl{Strategy: &strategy, OnSuccess: &onSuccess},
+			RoutingProvider:  iter8v1alpha1.RoutingProviderGateway,
+			RoutingReference: &corev1.ObjectReference{Kind: "HTTPRoute", Name: "reviews"},
+		},
+	}
+	instance.Status.InitializeConditions()
+	return instance
+}
+
+// newGatewayTestObjects returns the target services, and the HTTPRoute "reviews" with the given rules if any
+func newGatewayTestObjects(g *gomega.GomegaWithT, rules ...interface{}) []runtime.Object {
+	objs := newTestServices("bookinfo", "reviews-v1", "reviews-v2")
+	objs = append(objs, &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "bookinfo"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 9080}}},
+	})
+	if len(rules) == 0 {
+		return objs
+	}
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	route.SetName("reviews")
+	route.SetNamespace("bookinfo")
+	g.Expect(unstructured.SetNestedSlice(route.Object, rules, "spec", "rules")).To(gomega.Succeed())
+	return append(objs, route)
+}
+
+func TestGatewayRouterNewHTTPRoute(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := newTestContext()
+	instance := newGatewayTestExperiment()
+	r := newFakeReconciler(g, newGatewayTestObjects(g)...)
+	name := types.NamespacedName{Name: "reviews", Namespace: "bookinfo"}
+
+	// the HTTPRoute named by the routing reference is attached to the target service, on its first port
+	g.Expect(newGatewayRouter(r).Init(ctx, instance)).To(gomega.Succeed())
+	route := getTestObject(g, r, HTTPRouteGVK, name)
+	parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	g.Expect(parentRefs).To(gomega.HaveLen(1))
+	g.Expect(parentRefs[0]).To(gomega.HaveKeyWithValue("name", "reviews"))
+	refs := backendRefs(routeRules(route)[0])
+	g.Expect(refs).To(gomega.HaveLen(2))
+	for _, ref := range refs {
+		g.Expect(ref).To(gomega.HaveKeyWithValue("kind", "Service"))
+		g.Expect(ref).To(gomega.HaveKeyWithValue("port", int64(9080)))
+	}
+
+	// an HTTPRoute created by the experiment is removed when the experiment is deleted
+	g.Expect(newGatewayRouter(r).Finalize(ctx, instance)).To(gomega.Succeed())
+	route = &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	g.Expect(errors.IsNotFound(r.Get(ctx, name, route))).To(gomega.BeTrue())
+}
+
+func TestGatewayRouterExistingHTTPRoute(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := newTestContext()
+	instance := newGatewayTestExperiment()
+	rules := []interface{}{
+		map[string]interface{}{"backendRefs": []interface{}{
+			map[string]interface{}{"name": "reviews-v1", "port": int64(9080), "weight": int64(90)},
+			map[string]interface{}{"name": "reviews-legacy", "port": int64(9080), "weight": int64(10)},
+		}},
+		map[string]interface{}{"backendRefs": []interface{}{
+			map[string]interface{}{"name": "ratings", "port": int64(9080)},
+		}},
+	}
+	r := newFakeReconciler(g, newGatewayTestObjects(g, rules...)...)
+	name := types.NamespacedName{Name: "reviews", Namespace: "bookinfo"}
+
+	g.Expect(newGatewayRouter(r).Init(ctx, instance)).To(gomega.Equal(errSnapshotTaken))
+	router := newGatewayRouter(r)
+	g.Expect(router.Init(ctx, instance)).To(gomega.Succeed())
+
+	// the refs of the candidates are modelled on the ref of the baseline, and other rules are left alone
+	route := getTestObject(g, r, HTTPRouteGVK, name)
+	refs := backendRefs(routeRules(route)[0])
+	g.Expect(refs).To(gomega.HaveLen(3))
+	g.Expect(refs[2]).To(gomega.Equal(map[string]interface{}{"name": "reviews-v2", "port": int64(9080), "weight": int64(0)}))
+	g.Expect(routeRules(route)[1]).To(gomega.Equal(rules[1]))
+
+	// the rules are restored exactly as they were
+	g.Expect(router.SetWeights(ctx, instance, 50, []int{50})).To(gomega.Succeed())
+	instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+	g.Expect(router.Rollback(ctx, instance)).To(gomega.Succeed())
+	route = getTestObject(g, r, HTTPRouteGVK, name)
+	g.Expect(routeRules(route)).To(gomega.Equal(rules))
+}
@@ -0,0 +1,55 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// recordIteration adds the iteration in progress, numbered CurrentIteration+1, to the history of the experiment.
+// It must be called after the traffic split of the iteration is set in the status; err is the error
+// which interrupted the iteration, if any.
+func recordIteration(instance *iter8v1alpha1.Experiment, err error) {
+	record := iter8v1alpha1.IterationRecord{
+		Iteration:    instance.Status.CurrentIteration + 1,
+		Timestamp:    metav1.Now(),
+		TrafficSplit: instance.Status.TrafficSplit,
+	}
+
+	// assessments are only recorded when they come from this iteration
+	assessed := err == nil && getStrategy(instance) != iter8v1alpha1.StrategyIncrementWithoutCheck
+	for _, name := range instance.Spec.TargetService.GetCandidates() {
+		status := instance.Status.GetCandidateStatus(name)
+		candidate := iter8v1alpha1.CandidateRecord{
+			Name:              name,
+			TrafficPercentage: status.TrafficPercentage,
+		}
+		if assessed {
+			candidate.SuccessCriteria = append([]iter8v1alpha1.CriterionOutcome(nil), status.SuccessCriteria...)
+		}
+		record.Candidates = append(record.Candidates, candidate)
+	}
+	if assessed {
+		record.Conclusions = append([]string(nil), instance.Status.AssessmentSummary.Conclusions...)
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	instance.Status.RecordIteration(record)
+}
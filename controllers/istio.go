@@ -15,6 +15,9 @@ limitations under the License.
 package experiment
 
 import (
+	"strings"
+
+	"github.com/gogo/protobuf/types"
 	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -24,6 +27,9 @@ import (
 
 const (
 	IstioRuleSuffix = ".iter8-experiment"
+
+	// matchRoutePrefix names the HTTP routes sending user segments to candidates
+	matchRoutePrefix = "iter8-match-"
 )
 
 type DestinationRuleBuilder v1alpha3.DestinationRule
@@ -198,38 +204,55 @@ func (b *VirtualServiceBuilder) RemoveExperimentLabel() *VirtualServiceBuilder {
 	return b
 }
 
-func (b *VirtualServiceBuilder) WithRolloutPercent(service, ns string, rolloutPercent int32) *VirtualServiceBuilder {
+// WithRolloutPercent sets the weight of each candidate subset to the matching rolloutPercent entry,
+// leaving the rest of the traffic to Baseline
+func (b *VirtualServiceBuilder) WithRolloutPercent(service, ns string, subsets []string, rolloutPercent []int32) *VirtualServiceBuilder {
+	baselinePercent := int32(100)
+	for _, percent := range rolloutPercent {
+		baselinePercent -= percent
+	}
+
 	if b.Spec.Http != nil || len(b.Spec.Http) > 0 {
 		for i, http := range b.Spec.Http {
+			if isMatchRoute(http) {
+				continue
+			}
 			for j, route := range http.Route {
 				if equalHost(route.Destination.Host, ns, service, ns) {
 					if route.Destination.Subset == Baseline {
-						b.Spec.Http[i].Route[j].Weight = 100 - rolloutPercent
-					} else if route.Destination.Subset == Candidate {
-						b.Spec.Http[i].Route[j].Weight = rolloutPercent
+						b.Spec.Http[i].Route[j].Weight = baselinePercent
+						continue
+					}
+					for k, subset := range subsets {
+						if route.Destination.Subset == subset {
+							b.Spec.Http[i].Route[j].Weight = rolloutPercent[k]
+						}
 					}
 				}
 			}
 		}
 	} else {
 		b.Spec.Hosts = []string{service}
-		b.Spec.Http = append(b.Spec.Http, &networkingv1alpha3.HTTPRoute{
-			Route: []*networkingv1alpha3.HTTPRouteDestination{
-				{
-					Destination: &networkingv1alpha3.Destination{
-						Host:   service,
-						Subset: Baseline,
-					},
-					Weight: 100 - rolloutPercent,
-				},
-				{
-					Destination: &networkingv1alpha3.Destination{
-						Host:   service,
-						Subset: Candidate,
-					},
-					Weight: rolloutPercent,
+		routes := []*networkingv1alpha3.HTTPRouteDestination{
+			{
+				Destination: &networkingv1alpha3.Destination{
+					Host:   service,
+					Subset: Baseline,
 				},
+				Weight: baselinePercent,
 			},
+		}
+		for k, subset := range subsets {
+			routes = append(routes, &networkingv1alpha3.HTTPRouteDestination{
+				Destination: &networkingv1alpha3.Destination{
+					Host:   service,
+					Subset: subset,
+				},
+				Weight: rolloutPercent[k],
+			})
+		}
+		b.Spec.Http = append(b.Spec.Http, &networkingv1alpha3.HTTPRoute{
+			Route: routes,
 		})
 	}
 
@@ -238,6 +261,9 @@ func (b *VirtualServiceBuilder) WithRolloutPercent(service, ns string, rolloutPe
 
 func (b *VirtualServiceBuilder) AppendStableSubset(service, ns string) *VirtualServiceBuilder {
 	for i, http := range b.Spec.Http {
+		if isMatchRoute(http) {
+			continue
+		}
 		for j, route := range http.Route {
 			if equalHost(route.Destination.Host, ns, service, ns) {
 				b.Spec.Http[i].Route[j].Destination.Subset = Stable
@@ -280,10 +306,13 @@ func (b *VirtualServiceBuilder) WithExperimentRegisterd(exp string) *VirtualServ
 	return b
 }
 
-// WithStableToProgressing removes Stable subset while adds Baseline and Candidate subsets to the route
-func (b *VirtualServiceBuilder) WithStableToProgressing(service, ns string) *VirtualServiceBuilder {
+// WithStableToProgressing removes Stable subset while adds Baseline and candidate subsets to the route
+func (b *VirtualServiceBuilder) WithStableToProgressing(service, ns string, subsets []string) *VirtualServiceBuilder {
 	b = b.WithProgressingLabel()
 	for i, http := range b.Spec.Http {
+		if isMatchRoute(http) {
+			continue
+		}
 		stableIndex := -1
 		for j, route := range http.Route {
 			if equalHost(route.Destination.Host, ns, service, ns) {
@@ -293,25 +322,25 @@ func (b *VirtualServiceBuilder) WithStableToProgressing(service, ns string) *Vir
 		}
 		if stableIndex >= 0 {
 			stablePort := b.Spec.Http[i].Route[stableIndex].Destination.Port
-			// Add Baseline and Candidate entries in this HTTP section
-			b.Spec.Http[i].Route = append(b.Spec.Http[i].Route, []*networkingv1alpha3.HTTPRouteDestination{
-				{
-					Destination: &networkingv1alpha3.Destination{
-						Host:   service,
-						Subset: Baseline,
-						Port:   stablePort,
-					},
-					Weight: 100,
+			// Add Baseline and candidate entries in this HTTP section
+			b.Spec.Http[i].Route = append(b.Spec.Http[i].Route, &networkingv1alpha3.HTTPRouteDestination{
+				Destination: &networkingv1alpha3.Destination{
+					Host:   service,
+					Subset: Baseline,
+					Port:   stablePort,
 				},
-				{
+				Weight: 100,
+			})
+			for _, subset := range subsets {
+				b.Spec.Http[i].Route = append(b.Spec.Http[i].Route, &networkingv1alpha3.HTTPRouteDestination{
 					Destination: &networkingv1alpha3.Destination{
 						Host:   service,
-						Subset: Candidate,
+						Subset: subset,
 						Port:   stablePort,
 					},
 					Weight: 0,
-				},
-			}...)
+				})
+			}
 			// Remove Stable entry
 			b.Spec.Http[i].Route[stableIndex] = b.Spec.Http[i].Route[0]
 			b.Spec.Http[i].Route = b.Spec.Http[i].Route[1:]
@@ -322,7 +351,7 @@ func (b *VirtualServiceBuilder) WithStableToProgressing(service, ns string) *Vir
 }
 
 func (b *VirtualServiceBuilder) WithProgressingToStable(service, ns string, subset string) *VirtualServiceBuilder {
-	b = b.WithStableLabel()
+	b = b.WithStableLabel().RemoveMatchRoutes()
 	for i, http := range b.Spec.Http {
 		stableIndex := -1
 		for j, route := range http.Route {
@@ -338,10 +367,84 @@ func (b *VirtualServiceBuilder) WithProgressingToStable(service, ns string, subs
 			// Remove other entries
 			b.Spec.Http[i].Route[0] = b.Spec.Http[i].Route[stableIndex]
 			b.Spec.Http[i].Route = b.Spec.Http[i].Route[:1]
+			// Stop mirroring
+			b.Spec.Http[i].Mirror = nil
+			b.Spec.Http[i].MirrorPercent = nil
+
+			break
+		}
+	}
+	return b
+}
+
+// WithMatchRoutes replaces the match routes of the experiment with routes, ahead of the weighted route.
+// Destinations use the port of the weighted route to the service.
+func (b *VirtualServiceBuilder) WithMatchRoutes(service, ns string, routes []*networkingv1alpha3.HTTPRoute) *VirtualServiceBuilder {
+	b = b.RemoveMatchRoutes()
+	if len(routes) == 0 {
+		return b
+	}
 
+	var port *networkingv1alpha3.PortSelector
+	if http := weightedRoute(b.Spec.Http); http != nil {
+		for _, route := range http.Route {
+			if equalHost(route.Destination.Host, ns, service, ns) {
+				port = route.Destination.Port
+				break
+			}
+		}
+	}
+	for _, http := range routes {
+		for _, route := range http.Route {
+			route.Destination.Port = port
+		}
+	}
+
+	b.Spec.Http = append(routes, b.Spec.Http...)
+	return b
+}
+
+// WithMirror mirrors percent of the requests of the weighted route to subset, on the port of the baseline route
+func (b *VirtualServiceBuilder) WithMirror(service, ns, subset string, percent uint32) *VirtualServiceBuilder {
+	http := weightedRoute(b.Spec.Http)
+	if http == nil {
+		return b
+	}
+
+	var port *networkingv1alpha3.PortSelector
+	for _, route := range http.Route {
+		if equalHost(route.Destination.Host, ns, service, ns) && route.Destination.Subset == Baseline {
+			port = route.Destination.Port
 			break
 		}
 	}
+	http.Mirror = &networkingv1alpha3.Destination{
+		Host:   service,
+		Subset: subset,
+		Port:   port,
+	}
+	http.MirrorPercent = &types.UInt32Value{Value: percent}
+	return b
+}
+
+// RemoveMirror stops mirroring the requests of the weighted route
+func (b *VirtualServiceBuilder) RemoveMirror() *VirtualServiceBuilder {
+	if http := weightedRoute(b.Spec.Http); http != nil {
+		http.Mirror = nil
+		http.MirrorPercent = nil
+	}
+	return b
+}
+
+// RemoveMatchRoutes removes the match routes added by the experiment
+func (b *VirtualServiceBuilder) RemoveMatchRoutes() *VirtualServiceBuilder {
+	var https []*networkingv1alpha3.HTTPRoute
+	for _, http := range b.Spec.Http {
+		if !isMatchRoute(http) {
+			https = append(https, http)
+		}
+	}
+	b.Spec.Http = https
 	return b
 }
 
@@ -393,8 +496,27 @@ func updateSubset(dr *v1alpha3.DestinationRule, d *appsv1.Deployment, name strin
 	return update
 }
 
+// isMatchRoute tells whether http is a match route added by an experiment
+func isMatchRoute(http *networkingv1alpha3.HTTPRoute) bool {
+	return strings.HasPrefix(http.Name, matchRoutePrefix)
+}
+
+// weightedRoute returns the first route of https that is not a match route
+func weightedRoute(https []*networkingv1alpha3.HTTPRoute) *networkingv1alpha3.HTTPRoute {
+	for _, http := range https {
+		if !isMatchRoute(http) {
+			return http
+		}
+	}
+	return nil
+}
+
 func getWeight(subset string, vs *v1alpha3.VirtualService) int32 {
-	for _, route := range vs.Spec.Http[0].Route {
+	http := weightedRoute(vs.Spec.Http)
+	if http == nil {
+		return 0
+	}
+	for _, route := range http.Route {
 		if route.Destination.Subset == subset {
 			return route.Weight
 		}
@@ -0,0 +1,108 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestVirtualServiceRoutes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Spec.TargetService = iter8v1alpha1.TargetService{
+		ObjectReference: &corev1.ObjectReference{Name: "reviews"},
+		Baseline:        "reviews-v1",
+		Candidates:      []string{"reviews-v2", "reviews-v3"},
+	}
+	instance.Spec.TrafficControl.Match = []iter8v1alpha1.MatchRule{
+		{Candidate: "reviews-v3", Headers: []iter8v1alpha1.HeaderMatch{{Name: "X-User", Exact: "jason"}}},
+		{Cookie: &iter8v1alpha1.CookieMatch{Name: "group", Value: "beta"}, URIPrefix: "/api"},
+	}
+	subsets := candidateSubsets(instance)
+
+	vs := NewVirtualService("reviews", "reviews-rollout", "bookinfo").
+		WithRolloutPercent("reviews", "bookinfo", subsets, []int32{10, 20}).
+		WithMatchRoutes("reviews", "bookinfo", matchRoutes(instance)).
+		Build()
+	g.Expect(vs.Spec.Http).To(gomega.HaveLen(3))
+	g.Expect(vs.Spec.Http[0].Route[0].Destination.Subset).To(gomega.Equal("candidate-reviews-v3"))
+	g.Expect(vs.Spec.Http[0].Match[0].Headers).To(gomega.HaveKey("x-user"))
+	g.Expect(vs.Spec.Http[1].Route[0].Destination.Subset).To(gomega.Equal("candidate-reviews-v2"))
+	g.Expect(vs.Spec.Http[1].Match[0].Headers).To(gomega.HaveKey("cookie"))
+	g.Expect(vs.Spec.Http[1].Match[0].Uri.GetPrefix()).To(gomega.Equal("/api"))
+	g.Expect(getWeight("candidate-reviews-v3", vs)).To(gomega.Equal(int32(20)))
+
+	vs = NewVirtualServiceBuilder(vs).
+		WithRolloutPercent("reviews", "bookinfo", subsets, []int32{30, 20}).
+		Build()
+	g.Expect(vs.Spec.Http[0].Route[0].Weight).To(gomega.Equal(int32(100)))
+	g.Expect(getWeight(Baseline, vs)).To(gomega.Equal(int32(50)))
+
+	vs = NewVirtualServiceBuilder(vs).
+		WithMirror("reviews", "bookinfo", "candidate-reviews-v2", 50).
+		Build()
+	g.Expect(vs.Spec.Http[0].Mirror).To(gomega.BeNil())
+	g.Expect(vs.Spec.Http[2].Mirror.Subset).To(gomega.Equal("candidate-reviews-v2"))
+	g.Expect(vs.Spec.Http[2].MirrorPercent.GetValue()).To(gomega.Equal(uint32(50)))
+
+	vs = NewVirtualServiceBuilder(vs).
+		WithProgressingToStable("reviews", "bookinfo", "candidate-reviews-v2").
+		Build()
+	g.Expect(vs.Spec.Http).To(gomega.HaveLen(1))
+	g.Expect(vs.Spec.Http[0].Route).To(gomega.HaveLen(1))
+	g.Expect(vs.Spec.Http[0].Route[0].Destination.Subset).To(gomega.Equal(Stable))
+	g.Expect(vs.Spec.Http[0].Mirror).To(gomega.BeNil())
+}
+
+func TestRoutingSnapshot(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Spec.TargetService = iter8v1alpha1.TargetService{
+		ObjectReference: &corev1.ObjectReference{Name: "reviews"},
+		Baseline:        "reviews-v1",
+		Candidate:       "reviews-v2",
+	}
+	subsets := candidateSubsets(instance)
+
+	// stable rules splitting the traffic across two versions
+	rules := &IstioRoutingRules{
+		VirtualService: NewVirtualService("reviews", "reviews-rollout", "bookinfo").
+			WithRolloutPercent("reviews", "bookinfo", []string{"v3"}, []int32{30}).
+			WithStableLabel().RemoveExperimentLabel().Build(),
+		DestinationRule: NewDestinationRule("reviews", "reviews-rollout", "bookinfo").
+			WithStableLabel().RemoveExperimentLabel().Build(),
+	}
+	original := rules.VirtualService.DeepCopy().Spec
+	snapshot, err := rules.Snapshot()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	rules.VirtualService = NewVirtualServiceBuilder(rules.VirtualService).
+		WithRolloutPercent("reviews", "bookinfo", subsets, []int32{40}).
+		WithExperimentRegisterd("reviews-rollout").
+		Build()
+	g.Expect(rules.VirtualService.Spec).NotTo(gomega.Equal(original))
+
+	g.Expect(rules.Restore(snapshot)).To(gomega.Succeed())
+	g.Expect(rules.VirtualService.Spec).To(gomega.Equal(original))
+	g.Expect(rules.IsStable()).To(gomega.BeTrue())
+	g.Expect(rules.VirtualService.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+
+	g.Expect(rules.Restore(&iter8v1alpha1.RoutingSnapshot{})).NotTo(gomega.Succeed())
+}
@@ -18,52 +18,68 @@ package experiment
 import (
 	"context"
 	"encoding/json"
-	"time"
+	"fmt"
 
 	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
-	"github.com/iter8-tools/iter8-controller/pkg/analytics"
-	"github.com/iter8-tools/iter8-controller/pkg/analytics/checkandincrement"
-	"github.com/iter8-tools/iter8-controller/pkg/analytics/epsilongreedy"
 	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
 )
 
-func (r *ExperimentReconciler) syncKnative(context context.Context, instance *iter8v1alpha1.Experiment) (reconcile.Result, error) {
-	log := Logger(context)
+func init() {
+	registerRouter(KnativeServiceV1Alpha1, iter8v1alpha1.RoutingProviderKnative, newKnativeRouter)
+}
 
-	// Get Knative service
-	serviceName := instance.Spec.TargetService.Name
-	serviceNamespace := instance.Spec.TargetService.Namespace
-	if serviceNamespace == "" {
-		serviceNamespace = instance.Namespace
+// knativeRouter routes the traffic of a Knative service between its revisions with the traffic block of the service
+type knativeRouter struct {
+	r *ExperimentReconciler
+
+	kservice         *servingv1alpha1.Service
+	baselineTraffic  *servingv1alpha1.TrafficTarget
+	candidateTraffic []*servingv1alpha1.TrafficTarget
+}
+
+func newKnativeRouter(r *ExperimentReconciler) Router {
+	return &knativeRouter{r: r}
+}
+
+// getService fetches the Knative service of the experiment
+func (k *knativeRouter) getService(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	if k.kservice != nil {
+		return nil
 	}
 
+	serviceName := instance.Spec.TargetService.Name
 	kservice := &servingv1alpha1.Service{}
-	err := r.Get(context, types.NamespacedName{Name: serviceName, Namespace: serviceNamespace}, kservice)
-	if err != nil {
-		r.MarkTargetsError(context, instance, "Missing Service %s", serviceName)
-		err = r.Status().Update(context, instance)
-		if err != nil {
-			return reconcile.Result{}, err
-		}
-		return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+	if err := k.r.Get(context, types.NamespacedName{Name: serviceName, Namespace: getServiceNamespace(instance)}, kservice); err != nil {
+		k.r.MarkTargetsError(context, instance, "Missing Service %s", serviceName)
+		return err
+	}
+	k.kservice = kservice
+	return nil
+}
+
+// Init implements Router. It links the Knative service to the experiment and resolves the traffic targets
+// of the baseline and candidate revisions
+func (k *knativeRouter) Init(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	if err := k.getService(context, instance); err != nil {
+		return err
 	}
+	kservice := k.kservice
 
 	if kservice.Spec.Template == nil {
-		r.MarkTargetsError(context, instance, "%s", "Missing Template")
-		return reconcile.Result{}, r.Status().Update(context, instance)
+		k.r.MarkTargetsError(context, instance, "%s", "Missing Template")
+		return fmt.Errorf("MissingTemplate")
 	}
 
 	// link service to this experiment. Only one experiment can control a service
 	labels := kservice.GetLabels()
 	if experiment, found := labels[experimentLabel]; found && experiment != instance.GetName() {
-		r.MarkTargetsError(context, instance, "service is already controlled by experiment %s", experiment)
-		return reconcile.Result{}, r.Status().Update(context, instance)
+		k.r.MarkTargetsError(context, instance, "service is already controlled by experiment %s", experiment)
+		return fmt.Errorf("ServiceControlledByExperiment: %s", experiment)
 	}
 
 	if labels == nil {
@@ -71,247 +87,236 @@ func (r *ExperimentReconciler) syncKnative(context context.Context, instance *it
 	}
 
 	if _, ok := labels[experimentLabel]; !ok {
+		// The traffic block is restored as it was if the experiment fails
+		if instance.Status.RoutingSnapshot == nil {
+			snapshot, err := snapshotTraffic(kservice)
+			if err != nil {
+				k.r.MarkTargetsError(context, instance, "Fail to snapshot traffic: %v", err)
+				return err
+			}
+			instance.Status.RoutingSnapshot = snapshot
+			return errSnapshotTaken
+		}
 		labels[experimentLabel] = instance.GetName()
 		kservice.SetLabels(labels)
-		if err = r.Update(context, kservice); err != nil {
-			return reconcile.Result{}, err
+		if err := k.r.Update(context, kservice); err != nil {
+			return err
 		}
 	}
 
 	// Check the experiment targets existing traffic targets
-	ksvctraffic := kservice.Spec.Traffic
-	if ksvctraffic == nil {
-		r.MarkTargetsError(context, instance, "%s", "MissingTraffic")
-		return reconcile.Result{}, r.Status().Update(context, instance)
+	if kservice.Spec.Traffic == nil {
+		k.r.MarkTargetsError(context, instance, "%s", "MissingTraffic")
+		return fmt.Errorf("MissingTraffic")
 	}
 
 	baseline := instance.Spec.TargetService.Baseline
-	baselineTraffic := getTrafficByName(kservice, baseline)
-	candidate := instance.Spec.TargetService.Candidate
-	candidateTraffic := getTrafficByName(kservice, candidate)
-
-	if baselineTraffic == nil {
-		r.MarkTargetsError(context, instance, "Missing Baseline Revision: %s", baseline)
-		instance.Status.TrafficSplit.Baseline = 0
-		if candidateTraffic == nil {
-			instance.Status.TrafficSplit.Candidate = 0
-		} else {
-			instance.Status.TrafficSplit.Candidate = int(*candidateTraffic.Percent)
-		}
+	k.baselineTraffic = getTrafficByName(kservice, baseline)
+	candidates := instance.Spec.TargetService.GetCandidates()
+	k.candidateTraffic = make([]*servingv1alpha1.TrafficTarget, len(candidates))
+	for i, candidate := range candidates {
+		k.candidateTraffic[i] = getTrafficByName(kservice, candidate)
+	}
 
-		return reconcile.Result{}, r.Status().Update(context, instance)
-	}
-
-	if candidateTraffic == nil {
-		r.MarkTargetsError(context, instance, "Missing Candidate Revision: %s", candidate)
-		instance.Status.TrafficSplit.Baseline = int(*baselineTraffic.Percent)
-		instance.Status.TrafficSplit.Candidate = 0
-		err = r.Status().Update(context, instance)
-		return reconcile.Result{}, err
-	}
-
-	r.MarkTargetsFound(context, instance)
-
-	traffic := instance.Spec.TrafficControl
-	now := time.Now()
-	interval, _ := traffic.GetIntervalDuration() // TODO: admissioncontrollervalidation
-
-	// check experiment is finished
-	if traffic.GetMaxIterations() <= instance.Status.CurrentIteration ||
-		instance.Spec.Assessment != iter8v1alpha1.AssessmentNull {
-
-		update := false
-		if experimentSucceeded(instance) {
-			// experiment is successful
-			switch traffic.GetOnSuccess() {
-			case "baseline":
-				if *candidateTraffic.Percent != 0 {
-					*candidateTraffic.Percent = 0
-					update = true
-				}
-				if *baselineTraffic.Percent != 100 {
-					*baselineTraffic.Percent = 100
-					update = true
-				}
-				instance.Status.TrafficSplit.Baseline = 100
-				instance.Status.TrafficSplit.Candidate = 0
-			case "candidate":
-				if *candidateTraffic.Percent != 100 {
-					*candidateTraffic.Percent = 100
-					update = true
-				}
-				if *baselineTraffic.Percent != 0 {
-					*baselineTraffic.Percent = 0
-					update = true
-				}
-				instance.Status.TrafficSplit.Baseline = 0
-				instance.Status.TrafficSplit.Candidate = 100
-			case "both":
-			}
-			r.MarkExperimentSucceeded(context, instance, "%s", successMsg(instance))
-		} else {
-			r.MarkExperimentFailed(context, instance, "%s", failureMsg(instance))
-
-			// Switch traffic back to baseline
-			if *candidateTraffic.Percent != 0 {
-				*candidateTraffic.Percent = 0
-				update = true
-			}
-			if *baselineTraffic.Percent != 100 {
-				*baselineTraffic.Percent = 100
-				update = true
-			}
-		}
+	if k.baselineTraffic == nil {
+		k.r.MarkTargetsError(context, instance, "Missing Baseline Revision: %s", baseline)
+		setTrafficSplit(instance, 0, getTrafficPercents(k.candidateTraffic))
+		return fmt.Errorf("MissingBaselineRevision: %s", baseline)
+	}
 
-		labels := kservice.GetLabels()
-		_, has := labels[experimentLabel]
-		if has || update {
-			delete(labels, experimentLabel)
+	for i, target := range k.candidateTraffic {
+		if target == nil {
+			k.r.MarkTargetsError(context, instance, "Missing Candidate Revision: %s", candidates[i])
+			setServiceTrafficSplit(instance, kservice)
+			return fmt.Errorf("MissingCandidateRevision: %s", candidates[i])
 		}
+	}
 
-		if has || update {
-			err := r.Update(context, kservice)
-			if err != nil {
-				return reconcile.Result{}, err // retry
-			}
-		}
+	k.r.MarkTargetsFound(context, instance)
+	return nil
+}
 
-		instance.Status.TrafficSplit.Baseline = int(*baselineTraffic.Percent)
-		instance.Status.TrafficSplit.Candidate = int(*candidateTraffic.Percent)
-		return reconcile.Result{}, r.Status().Update(context, instance)
+// Targets implements Router. It returns the core services of the baseline and candidate revisions
+func (k *knativeRouter) Targets(context context.Context, instance *iter8v1alpha1.Experiment) (interface{}, []interface{}, error) {
+	if err := k.getService(context, instance); err != nil {
+		return nil, nil, err
 	}
 
-	// Check if traffic should be updated.
-	if now.After(instance.Status.LastIncrementTime.Add(interval)) {
-		log.Info("process iteration.")
+	// TODO: should just get the service name. See issue #83
+	baseline, err := k.r.getServiceForRevision(context, k.kservice, instance.Spec.TargetService.Baseline)
+	if err != nil {
+		// TODO: maybe we want another condition
+		k.r.MarkTargetsError(context, instance, "Missing Core Service: %v", err)
+		return nil, nil, err
+	}
+	names := instance.Spec.TargetService.GetCandidates()
+	candidates := make([]interface{}, len(names))
+	for i, name := range names {
+		candidate, err := k.r.getServiceForRevision(context, k.kservice, name)
+		if err != nil {
+			k.r.MarkTargetsError(context, instance, "Missing Core Service: %v", err)
+			return nil, nil, err
+		}
+		candidates[i] = candidate
+	}
+	return baseline, candidates, nil
+}
 
-		newRolloutPercent := *candidateTraffic.Percent
+// Completed implements Router. Knative experiments end once the last iteration has run
+func (k *knativeRouter) Completed(instance *iter8v1alpha1.Experiment) bool {
+	return instance.Spec.TrafficControl.GetMaxIterations() <= instance.Status.CurrentIteration
+}
 
-		strategy := getStrategy(instance)
-		if iter8v1alpha1.StrategyIncrementWithoutCheck == strategy {
-			newRolloutPercent += int64(traffic.GetStepSize())
-		} else {
-			var analyticsService analytics.AnalyticsService
-			switch getStrategy(instance) {
-			case checkandincrement.Strategy:
-				analyticsService = checkandincrement.GetService()
-			case epsilongreedy.Strategy:
-				analyticsService = epsilongreedy.GetService()
-			}
+// GetWeights implements Router
+func (k *knativeRouter) GetWeights(instance *iter8v1alpha1.Experiment) (int, []int) {
+	baseline := getTrafficPercents([]*servingv1alpha1.TrafficTarget{k.baselineTraffic})
+	return baseline[0], getTrafficPercents(k.candidateTraffic)
+}
 
-			// Get underlying k8s services
-			// TODO: should just get the service name. See issue #83
-			baselineService, err := r.getServiceForRevision(context, kservice, baselineTraffic.RevisionName)
-			if err != nil {
-				// TODO: maybe we want another condition
-				r.MarkTargetsError(context, instance, "Missing Core Service: %v", err)
-				return reconcile.Result{}, r.Status().Update(context, instance)
+// SetWeights implements Router. Traffic targets other than the baseline and the candidates get no traffic
+func (k *knativeRouter) SetWeights(context context.Context, instance *iter8v1alpha1.Experiment, baseline int, candidates []int) error {
+	names := instance.Spec.TargetService.GetCandidates()
+	update := false
+	for i := range k.kservice.Spec.Traffic {
+		target := &k.kservice.Spec.Traffic[i]
+		percent := int64(0)
+		if target.RevisionName == instance.Spec.TargetService.Baseline {
+			percent = int64(baseline)
+		}
+		for j, candidate := range names {
+			if target.RevisionName == candidate {
+				percent = int64(candidates[j])
 			}
+		}
+		if target.Percent == nil || *target.Percent != percent {
+			target.Percent = &percent
+			update = true
+		}
+	}
+	if !update {
+		return nil
+	}
+	return k.r.Update(context, k.kservice) // TODO: patch?
+}
 
-			candidateService, err := r.getServiceForRevision(context, kservice, candidateTraffic.RevisionName)
-			if err != nil {
-				// TODO: maybe we want another condition
-				r.MarkTargetsError(context, instance, "Missing Core Service: %v", err)
-				return reconcile.Result{}, r.Status().Update(context, instance)
+// Promote implements Router
+func (k *knativeRouter) Promote(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	candidates := instance.Spec.TargetService.GetCandidates()
+	update := false
+	switch instance.Spec.TrafficControl.GetOnSuccess() {
+	case "baseline":
+		update = setRevisionTraffic(k.baselineTraffic, k.candidateTraffic, 100, make([]int, len(candidates)))
+	case "candidate":
+		split := make([]int, len(candidates))
+		for i, candidate := range candidates {
+			if candidate == instance.Status.Winner {
+				split[i] = 100
 			}
+		}
+		update = setRevisionTraffic(k.baselineTraffic, k.candidateTraffic, 0, split)
+	case "both":
+	}
+	return k.release(context, update)
+}
 
-			// Get latest analysis
-			payload, err := analyticsService.MakeRequest(instance, baselineService, candidateService)
-			if err != nil {
-				r.MarkAnalyticsServiceError(context, instance, "Can Not Compose Payload: %v", err)
-				if err := r.Status().Update(context, instance); err != nil {
-					return reconcile.Result{}, err
-				}
-				return reconcile.Result{RequeueAfter: 5 * time.Second}, r.Status().Update(context, instance)
-			}
-			response, err := analyticsService.Invoke(log, instance.Spec.Analysis.GetServiceEndpoint(), payload, analyticsService.GetPath())
-			if err != nil {
-				r.MarkAnalyticsServiceError(context, instance, "%s", err.Error())
-				if err := r.Status().Update(context, instance); err != nil {
-					return reconcile.Result{}, err
-				}
-				return reconcile.Result{RequeueAfter: 5 * time.Second}, err
-			}
+// Rollback implements Router. The traffic block goes back to its snapshot or else to the baseline
+func (k *knativeRouter) Rollback(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	update, err := rollbackTraffic(instance, k.kservice, k.baselineTraffic, k.candidateTraffic)
+	if err != nil {
+		return err
+	}
+	if err := k.release(context, update); err != nil {
+		return err
+	}
+	setServiceTrafficSplit(instance, k.kservice)
+	return nil
+}
 
-			if response.Assessment.Summary.AbortExperiment {
-				log.Info("ExperimentAborted. Rollback to Baseline.")
-				if *candidateTraffic.Percent != 0 || *baselineTraffic.Percent != 100 {
-					*baselineTraffic.Percent = 100
-					*candidateTraffic.Percent = 0
-					err := r.Update(context, kservice)
-					if err != nil {
-						return reconcile.Result{}, err // retry
-					}
-				}
-
-				instance.Status.TrafficSplit.Baseline = 100
-				instance.Status.TrafficSplit.Candidate = 0
-
-				r.MarkExperimentFailed(context, instance, "%s", "Aborted, Traffic: AllToBaseline.")
-				err := r.Update(context, instance)
-				if err != nil {
-					return reconcile.Result{}, err // retry
-				}
-			}
+// release unlinks the Knative service from the experiment, along with any pending traffic change
+func (k *knativeRouter) release(context context.Context, update bool) error {
+	labels := k.kservice.GetLabels()
+	_, has := labels[experimentLabel]
+	if !has && !update {
+		return nil
+	}
+	delete(labels, experimentLabel)
+	return k.r.Update(context, k.kservice)
+}
 
-			baselineTraffic := response.Baseline.TrafficPercentage
-			candidateTraffic := response.Candidate.TrafficPercentage
-			log.Info("NewTraffic", "baseline", baselineTraffic, "candidate", candidateTraffic)
-			newRolloutPercent = int64(candidateTraffic)
-
-			if response.LastState == nil {
-				instance.Status.AnalysisState.Raw = []byte("{}")
-			} else {
-				lastState, err := json.Marshal(response.LastState)
-				if err != nil {
-					r.MarkAnalyticsServiceError(context, instance, "ErrorAnalyticsResponse: %v", err)
-					return reconcile.Result{}, r.Status().Update(context, instance)
-				}
-				instance.Status.AnalysisState = runtime.RawExtension{Raw: lastState}
-			}
-			instance.Status.AssessmentSummary = response.Assessment.Summary
+// getTrafficPercents returns the traffic percentage of each target, 0 for missing ones
+func getTrafficPercents(targets []*servingv1alpha1.TrafficTarget) []int {
+	out := make([]int, len(targets))
+	for i, target := range targets {
+		if target != nil && target.Percent != nil {
+			out[i] = int(*target.Percent)
 		}
+	}
+	return out
+}
 
-		// Set traffic percentable on all routes
-		needUpdate := false
-		for i := range ksvctraffic {
-			target := &ksvctraffic[i]
-			if target.RevisionName == baseline {
-				if *target.Percent != 100-int64(newRolloutPercent) {
-					*target.Percent = 100 - int64(newRolloutPercent)
-					needUpdate = true
-				}
-			} else if target.RevisionName == candidate {
-				if *target.Percent != int64(newRolloutPercent) {
-					*target.Percent = int64(newRolloutPercent)
-					needUpdate = true
-				}
-			} else {
-				if *target.Percent != 0 {
-					*target.Percent = 0
-					needUpdate = true
-				}
-			}
-		}
-		if needUpdate {
-			log.Info("update traffic", "rolloutPercent", newRolloutPercent)
-			r.MarkExperimentProgress(context, instance, true, "New Traffic, baseline: %d, candidate: %d",
-				instance.Status.TrafficSplit.Baseline, instance.Status.TrafficSplit.Candidate)
-			err = r.Update(context, kservice) // TODO: patch?
-			if err != nil {
-				// TODO: the analysis service will be called again upon retry. Maybe we do want that.
-				return reconcile.Result{}, err
-			}
+// setRevisionTraffic sets the traffic percentage of baseline and candidate revisions.
+// Returns true if any percentage has changed
+func setRevisionTraffic(baselineTraffic *servingv1alpha1.TrafficTarget, candidateTraffic []*servingv1alpha1.TrafficTarget,
+	baseline int, candidates []int) bool {
+	update := false
+	if *baselineTraffic.Percent != int64(baseline) {
+		*baselineTraffic.Percent = int64(baseline)
+		update = true
+	}
+	for i, target := range candidateTraffic {
+		if *target.Percent != int64(candidates[i]) {
+			*target.Percent = int64(candidates[i])
+			update = true
 		}
+	}
+	return update
+}
+
+// snapshotTraffic returns the traffic block of the Knative service, to be restored with restoreTraffic
+func snapshotTraffic(kservice *servingv1alpha1.Service) (*iter8v1alpha1.RoutingSnapshot, error) {
+	traffic, err := json.Marshal(kservice.Spec.Traffic)
+	if err != nil {
+		return nil, err
+	}
+	return &iter8v1alpha1.RoutingSnapshot{Traffic: &runtime.RawExtension{Raw: traffic}}, nil
+}
 
-		instance.Status.CurrentIteration++
-		instance.Status.LastIncrementTime = metav1.NewTime(now)
+// restoreTraffic sets the traffic block of the Knative service back to the snapshot.
+// It returns whether the traffic block changed
+func restoreTraffic(snapshot *iter8v1alpha1.RoutingSnapshot, kservice *servingv1alpha1.Service) (bool, error) {
+	if snapshot.Traffic == nil {
+		return false, fmt.Errorf("RoutingSnapshotWithoutTraffic")
+	}
+	traffic := []servingv1alpha1.TrafficTarget{}
+	if err := json.Unmarshal(snapshot.Traffic.Raw, &traffic); err != nil {
+		return false, err
+	}
+	if equality.Semantic.DeepEqual(traffic, kservice.Spec.Traffic) {
+		return false, nil
 	}
+	kservice.Spec.Traffic = traffic
+	return true, nil
+}
 
-	r.MarkExperimentProgress(context, instance, false, "Iteration %d Completed", instance.Status.CurrentIteration)
-	instance.Status.TrafficSplit.Baseline = int(*baselineTraffic.Percent)
-	instance.Status.TrafficSplit.Candidate = int(*candidateTraffic.Percent)
-	return reconcile.Result{RequeueAfter: interval}, r.Status().Update(context, instance)
+// rollbackTraffic restores the traffic block of the Knative service if a snapshot was taken,
+// or else sends all the traffic to the baseline. It returns whether the traffic block changed
+func rollbackTraffic(instance *iter8v1alpha1.Experiment, kservice *servingv1alpha1.Service,
+	baselineTraffic *servingv1alpha1.TrafficTarget, candidateTraffic []*servingv1alpha1.TrafficTarget) (bool, error) {
+	if snapshot := instance.Status.RoutingSnapshot; snapshot != nil {
+		return restoreTraffic(snapshot, kservice)
+	}
+	return setRevisionTraffic(baselineTraffic, candidateTraffic, 100, make([]int, len(candidateTraffic))), nil
+}
+
+// setServiceTrafficSplit records the traffic split of the Knative service
+func setServiceTrafficSplit(instance *iter8v1alpha1.Experiment, kservice *servingv1alpha1.Service) {
+	baseline := getTrafficPercents([]*servingv1alpha1.TrafficTarget{getTrafficByName(kservice, instance.Spec.TargetService.Baseline)})
+	candidates := instance.Spec.TargetService.GetCandidates()
+	candidateTraffic := make([]*servingv1alpha1.TrafficTarget, len(candidates))
+	for i, candidate := range candidates {
+		candidateTraffic[i] = getTrafficByName(kservice, candidate)
+	}
+	setTrafficSplit(instance, baseline[0], getTrafficPercents(candidateTraffic))
 }
 
 func getTrafficByName(service *servingv1alpha1.Service, name string) *servingv1alpha1.TrafficTarget {
@@ -338,49 +343,49 @@ func (r *ExperimentReconciler) getServiceForRevision(context context.Context, ks
 	return service, nil
 }
 
-func (r *ExperimentReconciler) finalizeKnative(context context.Context, instance *iter8v1alpha1.Experiment) (reconcile.Result, error) {
+// Finalize implements Router
+func (k *knativeRouter) Finalize(context context.Context, instance *iter8v1alpha1.Experiment) error {
 	completed := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionExperimentCompleted)
-	if completed != nil && completed.Status != corev1.ConditionTrue {
-		// Do a rollback
-
-		// Get Knative service
-		serviceName := instance.Spec.TargetService.Name
-		serviceNamespace := getServiceNamespace(instance)
-
-		kservice := &servingv1alpha1.Service{}
-		err := r.Get(context, types.NamespacedName{Name: serviceName, Namespace: serviceNamespace}, kservice)
-		if err != nil {
-			return reconcile.Result{}, removeFinalizer(context, r, instance, Finalizer)
-		}
+	if completed == nil || completed.Status == corev1.ConditionTrue {
+		return nil
+	}
 
-		// Check the experiment targets existing traffic targets
-		ksvctraffic := kservice.Spec.Traffic
-		if ksvctraffic == nil {
-			return reconcile.Result{}, removeFinalizer(context, r, instance, Finalizer)
-		}
+	// Do a rollback
+	if err := k.getService(context, instance); err != nil {
+		return nil
+	}
+	kservice := k.kservice
 
-		baseline := instance.Spec.TargetService.Baseline
-		baselineTraffic := getTrafficByName(kservice, baseline)
-		if baselineTraffic == nil {
-			return reconcile.Result{}, removeFinalizer(context, r, instance, Finalizer)
+	// Restore the traffic block as it was before the experiment
+	if instance.Status.RoutingSnapshot != nil {
+		if _, err := restoreTraffic(instance.Status.RoutingSnapshot, kservice); err != nil {
+			return err
 		}
+		delete(kservice.Labels, experimentLabel)
+		return k.r.Update(context, kservice)
+	}
 
-		candidate := instance.Spec.TargetService.Candidate
-		candidateTraffic := getTrafficByName(kservice, candidate)
-		if candidateTraffic == nil {
-			return reconcile.Result{}, removeFinalizer(context, r, instance, Finalizer)
-		}
+	// Check the experiment targets existing traffic targets
+	if kservice.Spec.Traffic == nil {
+		return nil
+	}
 
-		if *baselineTraffic.Percent != 100 || *candidateTraffic.Percent != 0 {
-			*baselineTraffic.Percent = 100
-			*candidateTraffic.Percent = 0
+	baselineTraffic := getTrafficByName(kservice, instance.Spec.TargetService.Baseline)
+	if baselineTraffic == nil {
+		return nil
+	}
 
-			err = r.Update(context, kservice) // TODO: patch?
-			if err != nil {
-				return reconcile.Result{}, err
-			}
+	candidates := instance.Spec.TargetService.GetCandidates()
+	candidateTraffic := make([]*servingv1alpha1.TrafficTarget, len(candidates))
+	for i, candidate := range candidates {
+		candidateTraffic[i] = getTrafficByName(kservice, candidate)
+		if candidateTraffic[i] == nil {
+			return nil
 		}
 	}
 
-	return reconcile.Result{}, removeFinalizer(context, r, instance, Finalizer)
+	if setRevisionTraffic(baselineTraffic, candidateTraffic, 100, make([]int, len(candidates))) {
+		return k.r.Update(context, kservice) // TODO: patch?
+	}
+	return nil
 }
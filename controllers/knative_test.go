@@ -0,0 +1,69 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// newKnativeTestService returns a Knative service routing the given percents to revisions reviews-v1 and reviews-v2
+func newKnativeTestService(percents ...int64) *servingv1alpha1.Service {
+	kservice := &servingv1alpha1.Service{ObjectMeta: metav1.ObjectMeta{Name: "reviews", Namespace: "bookinfo"}}
+	kservice.Spec.Template = &servingv1alpha1.RevisionTemplateSpec{}
+	for i, revision := range []string{"reviews-v1", "reviews-v2"} {
+		target := servingv1alpha1.TrafficTarget{}
+		target.RevisionName = revision
+		target.Percent = &percents[i]
+		kservice.Spec.Traffic = append(kservice.Spec.Traffic, target)
+	}
+	return kservice
+}
+
+func TestKnativeRouterExistingTraffic(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := newTestContext()
+	instance := newIstioTestExperiment("reviews-v2-rollout", "reviews")
+	instance.Spec.TargetService.APIVersion = KnativeServiceV1Alpha1
+	r := newFakeReconciler(g, newKnativeTestService(90, 10))
+	name := types.NamespacedName{Name: "reviews", Namespace: "bookinfo"}
+
+	// the traffic block is snapshotted, and the service is linked only once the snapshot is persisted
+	g.Expect(newKnativeRouter(r).Init(ctx, instance)).To(gomega.Equal(errSnapshotTaken))
+	g.Expect(instance.Status.RoutingSnapshot).NotTo(gomega.BeNil())
+	kservice := &servingv1alpha1.Service{}
+	g.Expect(r.Get(ctx, name, kservice)).To(gomega.Succeed())
+	g.Expect(kservice.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+
+	router := newKnativeRouter(r)
+	g.Expect(router.Init(ctx, instance)).To(gomega.Succeed())
+	g.Expect(r.Get(ctx, name, kservice)).To(gomega.Succeed())
+	g.Expect(kservice.GetLabels()).To(gomega.HaveKeyWithValue(experimentLabel, instance.Name))
+
+	// a failed experiment restores the traffic block as it was
+	g.Expect(router.SetWeights(ctx, instance, 50, []int{50})).To(gomega.Succeed())
+	instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+	g.Expect(router.Rollback(ctx, instance)).To(gomega.Succeed())
+	g.Expect(r.Get(ctx, name, kservice)).To(gomega.Succeed())
+	g.Expect(kservice.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+	g.Expect(getTrafficPercents([]*servingv1alpha1.TrafficTarget{&kservice.Spec.Traffic[0], &kservice.Spec.Traffic[1]})).
+		To(gomega.Equal([]int{90, 10}))
+}
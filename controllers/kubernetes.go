@@ -17,97 +17,268 @@ package experiment
 
 import (
 	"context"
-	"time"
+	"fmt"
 
 	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 )
 
-func (r *ExperimentReconciler) syncKubernetes(context context.Context, instance *iter8v1alpha1.Experiment) (reconcile.Result, error) {
-	updateStatus, err := r.checkOrInitRules(context, instance)
-	if updateStatus {
-		if err := r.Status().Update(context, instance); err != nil && !validUpdateErr(err) {
-			log.Info("Fail to update status: %v", err)
-			// End experiment
-			return reconcile.Result{}, nil
-		}
+func init() {
+	registerRouter(KubernetesService, iter8v1alpha1.RoutingProviderIstio, newIstioRouter)
+}
+
+// istioRouter routes the traffic of a Kubernetes service between deployments with Istio virtual services
+// and destination rules
+type istioRouter struct {
+	r *ExperimentReconciler
+
+	// Targets and routing rules are resolved anew by each reconcile and never shared between experiments
+	rules    *IstioRoutingRules
+	targets  *Targets
+	resolved bool
+}
+
+func newIstioRouter(r *ExperimentReconciler) Router {
+	return &istioRouter{r: r, rules: &IstioRoutingRules{}, targets: InitTargets()}
+}
+
+// Init implements Router
+func (i *istioRouter) Init(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	if err := i.r.checkOrInitRules(context, instance, i.rules); err != nil {
+		return err
 	}
-	if err != nil {
-		return reconcile.Result{}, err
+	if err := i.r.detectTargets(context, instance, i.rules, i.targets); err != nil {
+		return err
 	}
+	i.resolved = true
+	return nil
+}
 
-	updateStatus, err = r.detectTargets(context, instance)
-	if updateStatus {
-		if err := r.Status().Update(context, instance); err != nil && !validUpdateErr(err) {
-			log.Info("Fail to update status: %v", err)
-			// End experiment
-			return reconcile.Result{}, nil
+// Targets implements Router. It returns the baseline and candidate deployments
+func (i *istioRouter) Targets(context context.Context, instance *iter8v1alpha1.Experiment) (interface{}, []interface{}, error) {
+	if !i.resolved {
+		if missing, err := i.r.getTargets(context, instance, i.targets); err != nil {
+			i.r.MarkTargetsError(context, instance, "Missing %s", missing)
+			return nil, nil, err
 		}
+		i.resolved = true
+	}
+
+	candidates := make([]interface{}, len(i.targets.Candidates))
+	for j, candidate := range i.targets.Candidates {
+		candidates[j] = candidate
+	}
+	return i.targets.Baseline, candidates, nil
+}
+
+// Completed implements Router
+func (i *istioRouter) Completed(instance *iter8v1alpha1.Experiment) bool {
+	return experimentCompleted(instance)
+}
+
+// GetWeights implements Router
+func (i *istioRouter) GetWeights(instance *iter8v1alpha1.Experiment) (int, []int) {
+	subsets := candidateSubsets(instance)
+	candidates := make([]int, len(subsets))
+	for j, subset := range subsets {
+		candidates[j] = int(i.rules.GetWeight(subset))
 	}
-	if err != nil {
-		// retry in 5 secs
-		log.Info("retry in 5 secs")
-		return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+	return int(i.rules.GetWeight(Baseline)), candidates
+}
+
+// SetWeights implements Router. The baseline gets the traffic left by the candidates
+func (i *istioRouter) SetWeights(context context.Context, instance *iter8v1alpha1.Experiment, baseline int, candidates []int) error {
+	weights := make([]int32, len(candidates))
+	for j, percent := range candidates {
+		weights[j] = int32(percent)
+	}
+	return i.rules.UpdateRolloutPercent(instance.Spec.TargetService.Name, getServiceNamespace(instance),
+		candidateSubsets(instance), weights, i.r.istioClient)
+}
+
+// Promote implements Router
+func (i *istioRouter) Promote(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return i.r.cleanUpIstio(context, instance, i.rules, i.targets)
+}
+
+// Rollback implements Router
+func (i *istioRouter) Rollback(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return i.r.cleanUpIstio(context, instance, i.rules, i.targets)
+}
+
+// Finalize implements Router
+func (i *istioRouter) Finalize(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	completed := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionExperimentCompleted)
+	if completed == nil || completed.Status == corev1.ConditionTrue {
+		return nil
+	}
+
+	if err := i.rules.GetRoutingRules(instance, i.r.istioClient); err != nil {
+		return err
+	}
+	// nothing to clean up if the routing rules are not controlled by this experiment
+	if i.rules.IsEmpty() || !i.rules.IsProgressing(instance.GetName()) {
+		return nil
 	}
 
-	if completed, err := r.checkExperimentComplete(context, instance); completed {
-		if err := r.Status().Update(context, instance); err != nil && !validUpdateErr(err) {
-			log.Info("Fail to update status: %v", err)
-			// End experiment
-			return reconcile.Result{}, nil
+	// rules which existed before the experiment are restored whether or not the targets are still presented
+	restored := false
+	if snapshot := instance.Status.RoutingSnapshot; snapshot != nil {
+		if err := i.rules.Restore(snapshot); err != nil {
+			return err
 		}
-		// Experiment completed
-		return reconcile.Result{}, err
-	}
-
-	now := time.Now()
-	traffic := instance.Spec.TrafficControl
-	// TODO: check err in getting the time value
-	interval, _ := traffic.GetIntervalDuration()
-	if now.After(instance.Status.LastIncrementTime.Add(interval)) || withRecheckRequirement(instance) {
-		err := r.progressExperiment(context, instance)
-		if err := r.Status().Update(context, instance); err != nil && !validUpdateErr(err) {
-			log.Info("Fail to update status: %v", err)
-			// End experiment
-			return reconcile.Result{}, nil
+		if err := i.rules.UpdateRemoveRules(i.r.istioClient); err != nil {
+			return err
 		}
+		restored = true
+	}
 
-		if err != nil {
-			// TODO: may need a better handling method
-			// retry in 5 sec
-			log.Info("retry in 5 secs", "err", err)
-			return reconcile.Result{RequeueAfter: 5 * time.Second}, err
+	// clean up can be done only when all targets are presented
+	found := false
+	targetsFound := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionTargetsProvided)
+	if targetsFound != nil && targetsFound.Status == corev1.ConditionTrue {
+		_, err := i.r.getTargets(context, instance, i.targets)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
 		}
+		found = err == nil
+	}
 
-		if experimentCompleted(instance) {
-			return reconcile.Result{Requeue: true}, nil
+	switch {
+	case found && restored:
+		instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+		return i.targets.Cleanup(context, instance, i.r.Client)
+	case found:
+		// Execute in failure condition
+		instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+		return i.r.cleanUpIstio(context, instance, i.rules, i.targets)
+	case restored:
+		return nil
+	}
+	return i.r.stopMirror(instance, i.rules)
+}
+
+// checkOrInitRules looks up the routing rules of the target service and creates them if none exists
+func (r *ExperimentReconciler) checkOrInitRules(context context.Context, instance *iter8v1alpha1.Experiment,
+	rules *IstioRoutingRules) error {
+	if err := rules.GetRoutingRules(instance, r.istioClient); err != nil {
+		r.MarkRoutingRulesError(context, instance, "Error in getting routing rules: %v", err)
+		return err
+	}
+
+	if rules.IsEmpty() {
+		if err := rules.InitRoutingRules(instance, r.istioClient); err != nil {
+			r.MarkRoutingRulesError(context, instance, "Error in initializing routing rules: %v", err)
+			return err
 		}
+		r.MarkRoutingRulesReady(context, instance, "Init Routing Rules")
+		return nil
+	}
 
-		// Next iteration
-		return reconcile.Result{RequeueAfter: interval}, nil
+	if !rules.IsStable() && !rules.IsProgressing(instance.GetName()) {
+		r.MarkRoutingRulesError(context, instance, "Routing rules for %s are neither stable nor controlled by this experiment",
+			instance.Spec.TargetService.Name)
+		return fmt.Errorf("UnexpectedRoutingRules")
 	}
 
-	return reconcile.Result{}, nil
+	r.MarkRoutingRulesReady(context, instance, "")
+	return nil
 }
 
-func (r *ExperimentReconciler) finalizeIstio(context context.Context, instance *iter8v1alpha1.Experiment) (reconcile.Result, error) {
-	completed := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionExperimentCompleted)
-	if completed != nil && completed.Status != corev1.ConditionTrue {
-		targetsFound := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionTargetsProvided)
-		// clean up can be done only when all targets are presented
-		if targetsFound != nil && targetsFound.Status == corev1.ConditionTrue {
-			// Execute in failure condition
-			instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
-			if err := r.targets.Cleanup(context, instance, r.Client); err != nil {
-				return reconcile.Result{}, err
-			}
-			if err := r.rules.Cleanup(instance, r.targets, r.istioClient); err != nil {
-				return reconcile.Result{}, err
+// getTargets fetches the service and the baseline and candidate deployments of the experiment into targets.
+// On error, it also returns which target is missing
+func (r *ExperimentReconciler) getTargets(context context.Context, instance *iter8v1alpha1.Experiment,
+	targets *Targets) (string, error) {
+	serviceName := instance.Spec.TargetService.Name
+	serviceNamespace := getServiceNamespace(instance)
+
+	if err := r.Get(context, types.NamespacedName{Name: serviceName, Namespace: serviceNamespace}, targets.Service); err != nil {
+		return "Service " + serviceName, err
+	}
+
+	baseline := instance.Spec.TargetService.Baseline
+	if err := r.Get(context, types.NamespacedName{Name: baseline, Namespace: serviceNamespace}, targets.Baseline); err != nil {
+		return "Baseline " + baseline, err
+	}
+
+	for _, candidate := range instance.Spec.TargetService.GetCandidates() {
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(context, types.NamespacedName{Name: candidate, Namespace: serviceNamespace}, deployment); err != nil {
+			return "Candidate " + candidate, err
+		}
+		targets.Candidates = append(targets.Candidates, deployment)
+	}
+	return "", nil
+}
+
+// detectTargets resolves the service and the baseline and candidate deployments of the experiment,
+// and registers them in the routing rules
+func (r *ExperimentReconciler) detectTargets(context context.Context, instance *iter8v1alpha1.Experiment,
+	rules *IstioRoutingRules, targets *Targets) error {
+	serviceName := instance.Spec.TargetService.Name
+	serviceNamespace := getServiceNamespace(instance)
+
+	if missing, err := r.getTargets(context, instance, targets); err != nil {
+		r.MarkTargetsError(context, instance, "Missing %s", missing)
+		return err
+	}
+
+	// Take over stable rules only when all targets are presented
+	subsets := candidateSubsets(instance)
+	if rules.IsStable() {
+		// Rules which existed before the experiment are restored as they were if the experiment fails
+		if !rules.IsInit() && instance.Status.RoutingSnapshot == nil {
+			snapshot, err := rules.Snapshot()
+			if err != nil {
+				r.MarkRoutingRulesError(context, instance, "Fail to snapshot stable rules: %v", err)
+				return err
 			}
+			instance.Status.RoutingSnapshot = snapshot
+			return errSnapshotTaken
+		}
+		if err := rules.StableToProgressing(targets, instance.GetName(), serviceNamespace, subsets,
+			matchRoutes(instance), r.istioClient); err != nil {
+			r.MarkRoutingRulesError(context, instance, "Fail to convert stable rules: %v", err)
+			return err
 		}
 	}
 
-	return reconcile.Result{}, removeFinalizer(context, r, instance, Finalizer)
+	if err := rules.UpdateSubsets(targets, subsets, r.istioClient); err != nil {
+		r.MarkRoutingRulesError(context, instance, "Fail to update subsets: %v", err)
+		return err
+	}
+
+	// Mirror the baseline traffic to the candidate until the traffic ramp starts
+	mirror, percent := "", uint32(0)
+	if traffic := instance.Spec.TrafficControl; traffic.IsMirroring(instance.Status.CurrentIteration) {
+		mirror, percent = subsets[0], uint32(traffic.Mirror.GetPercentage())
+	}
+	if err := rules.UpdateMirror(serviceName, serviceNamespace, mirror, percent, r.istioClient); err != nil {
+		r.MarkRoutingRulesError(context, instance, "Fail to update mirror: %v", err)
+		return err
+	}
+
+	r.MarkTargetsFound(context, instance)
+	return nil
+}
+
+// cleanUpIstio settles targets and routing rules at the end of the experiment
+func (r *ExperimentReconciler) cleanUpIstio(context context.Context, instance *iter8v1alpha1.Experiment,
+	rules *IstioRoutingRules, targets *Targets) error {
+	if err := targets.Cleanup(context, instance, r.Client); err != nil {
+		return err
+	}
+	return rules.Cleanup(instance, targets, r.istioClient)
+}
+
+// stopMirror stops mirroring traffic to the candidate when the routing rules cannot be settled
+func (r *ExperimentReconciler) stopMirror(instance *iter8v1alpha1.Experiment, rules *IstioRoutingRules) error {
+	if instance.Spec.TrafficControl.Mirror == nil {
+		return nil
+	}
+	return rules.UpdateMirror(instance.Spec.TargetService.Name, getServiceNamespace(instance), "", 0, r.istioClient)
 }
@@ -0,0 +1,220 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func newIstioTestExperiment(name, service string) *iter8v1alpha1.Experiment {
+	strategy, onSuccess := iter8v1alpha1.StrategyIncrementWithoutCheck, "candidate"
+	instance := &iter8v1alpha1.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "bookinfo"},
+		Spec: iter8v1alpha1.ExperimentSpec{
+			TargetService: iter8v1alpha1.TargetService{
+				ObjectReference: &corev1.ObjectReference{APIVersion: KubernetesService, Name: service},
+				Baseline:        service + "-v1",
+				Candidate:       service + "-v2",
+			},
+			TrafficControl: iter8v1alpha1.TrafficControl{Strategy: &strategy, OnSuccess: &onSuccess},
+		},
+	}
+	instance.Status.InitializeConditions()
+	return instance
+}
+
+// newIstioTestObjects returns the target service of the given name with its deployments v1 and v2,
+// and stable routing rules to v1 if stable is set
+func newIstioTestObjects(service string, stable bool) []runtime.Object {
+	objs := newTestServices("bookinfo", service)
+	deployments := make([]*appsv1.Deployment, 2)
+	for i, version := range []string{"v1", "v2"} {
+		deployments[i] = &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: service + "-" + version, Namespace: "bookinfo"}}
+		deployments[i].Spec.Template.Labels = map[string]string{"app": service, "version": version}
+		objs = append(objs, deployments[i])
+	}
+	if !stable {
+		return objs
+	}
+	return append(objs,
+		NewDestinationRule(service, "", "bookinfo").WithStableDeployment(deployments[0]).RemoveExperimentLabel().Build(),
+		NewVirtualService(service, "", "bookinfo").WithNewStableSet(service).RemoveExperimentLabel().Build())
+}
+
+// getIstioTestRules fetches the routing rules of the given service from the fake Istio clientset of r
+func getIstioTestRules(g *gomega.GomegaWithT, r *ExperimentReconciler, service string) (*v1alpha3.DestinationRule, *v1alpha3.VirtualService) {
+	name := service + ".bookinfo" + IstioRuleSuffix
+	dr, err := r.istioClient.NetworkingV1alpha3().DestinationRules("bookinfo").Get(name, metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	vs, err := r.istioClient.NetworkingV1alpha3().VirtualServices("bookinfo").Get(name, metav1.GetOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	return dr, vs
+}
+
+func TestIstioRouterExistingRules(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := newTestContext()
+	instance := newIstioTestExperiment("reviews-v2-rollout", "reviews")
+	r := newFakeReconciler(g, newIstioTestObjects("reviews", true)...)
+
+	// the stable rules are snapshotted, and taken over only once the snapshot is persisted
+	g.Expect(newIstioRouter(r).Init(ctx, instance)).To(gomega.Equal(errSnapshotTaken))
+	g.Expect(instance.Status.RoutingSnapshot).NotTo(gomega.BeNil())
+	dr, vs := getIstioTestRules(g, r, "reviews")
+	g.Expect(dr.GetLabels()).To(gomega.HaveKeyWithValue(experimentRole, Stable))
+	g.Expect(vs.GetLabels()).To(gomega.HaveKeyWithValue(experimentRole, Stable))
+	g.Expect(vs.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+
+	router := newIstioRouter(r)
+	g.Expect(router.Init(ctx, instance)).To(gomega.Succeed())
+	dr, vs = getIstioTestRules(g, r, "reviews")
+	g.Expect(dr.GetLabels()).To(gomega.HaveKeyWithValue(experimentLabel, instance.Name))
+	g.Expect(vs.GetLabels()).To(gomega.HaveKeyWithValue(experimentRole, Progressing))
+	g.Expect(getWeight(Baseline, vs)).To(gomega.Equal(int32(100)))
+
+	// a failed experiment restores the rules as they were
+	g.Expect(router.SetWeights(ctx, instance, 50, []int{50})).To(gomega.Succeed())
+	instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+	g.Expect(router.Rollback(ctx, instance)).To(gomega.Succeed())
+	dr, vs = getIstioTestRules(g, r, "reviews")
+	g.Expect(vs.GetLabels()).To(gomega.HaveKeyWithValue(experimentRole, Stable))
+	g.Expect(vs.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+	g.Expect(vs.Spec.Http).To(gomega.HaveLen(1))
+	g.Expect(vs.Spec.Http[0].Route).To(gomega.HaveLen(1))
+	g.Expect(vs.Spec.Http[0].Route[0].Destination.Subset).To(gomega.Equal(Stable))
+	g.Expect(dr.Spec.Subsets).To(gomega.HaveLen(1))
+	g.Expect(dr.Spec.Subsets[0].Name).To(gomega.Equal(Stable))
+}
+
+func TestIstioRouterNewRules(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := newTestContext()
+	instance := newIstioTestExperiment("reviews-v2-rollout", "reviews")
+	r := newFakeReconciler(g, newIstioTestObjects("reviews", false)...)
+	subset := candidateSubsets(instance)[0]
+
+	// the rules are created when the target service has none, with a subset per deployment
+	g.Expect(newIstioRouter(r).Init(ctx, instance)).To(gomega.Succeed())
+	dr, vs := getIstioTestRules(g, r, "reviews")
+	g.Expect(dr.GetLabels()).To(gomega.HaveKeyWithValue(experimentInit, "True"))
+	g.Expect(dr.GetLabels()).To(gomega.HaveKeyWithValue(experimentLabel, instance.Name))
+	g.Expect(vs.GetLabels()).To(gomega.HaveKeyWithValue(experimentRole, Progressing))
+	g.Expect(dr.Spec.Subsets).To(gomega.HaveLen(2))
+	g.Expect(dr.Spec.Subsets[0].Name).To(gomega.Equal(Baseline))
+	g.Expect(dr.Spec.Subsets[0].Labels).To(gomega.HaveKeyWithValue("version", "v1"))
+	g.Expect(dr.Spec.Subsets[1].Name).To(gomega.Equal(subset))
+	g.Expect(dr.Spec.Subsets[1].Labels).To(gomega.HaveKeyWithValue("version", "v2"))
+	g.Expect(getWeight(Baseline, vs)).To(gomega.Equal(int32(100)))
+	g.Expect(getWeight(subset, vs)).To(gomega.BeZero())
+	g.Expect(instance.Status.RoutingSnapshot).To(gomega.BeNil())
+
+	// each reconcile discovers the rules instead of creating them again, and steps the traffic
+	stepSize := 20.0
+	instance.Spec.TrafficControl.TrafficStepSize = &stepSize
+	for i, percent := range []int32{20, 40} {
+		router := newIstioRouter(r)
+		g.Expect(router.Init(ctx, instance)).To(gomega.Succeed())
+		g.Expect(r.progressExperiment(ctx, instance, router)).To(gomega.Succeed())
+		_, vs = getIstioTestRules(g, r, "reviews")
+		g.Expect(getWeight(subset, vs)).To(gomega.Equal(percent))
+		g.Expect(getWeight(Baseline, vs)).To(gomega.Equal(100 - percent))
+		g.Expect(instance.Status.CurrentIteration).To(gomega.Equal(i + 1))
+	}
+	drs, err := r.istioClient.NetworkingV1alpha3().DestinationRules("bookinfo").List(metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(drs.Items).To(gomega.HaveLen(1))
+	vss, err := r.istioClient.NetworkingV1alpha3().VirtualServices("bookinfo").List(metav1.ListOptions{})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(vss.Items).To(gomega.HaveLen(1))
+
+	// the winner becomes the stable subset
+	instance.Status.Winner = "reviews-v2"
+	router := newIstioRouter(r)
+	g.Expect(router.Init(ctx, instance)).To(gomega.Succeed())
+	g.Expect(router.Promote(ctx, instance)).To(gomega.Succeed())
+	dr, vs = getIstioTestRules(g, r, "reviews")
+	g.Expect(vs.GetLabels()).To(gomega.HaveKeyWithValue(experimentRole, Stable))
+	g.Expect(vs.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+	g.Expect(dr.Spec.Subsets).To(gomega.HaveLen(1))
+	g.Expect(dr.Spec.Subsets[0].Name).To(gomega.Equal(Stable))
+	g.Expect(dr.Spec.Subsets[0].Labels).To(gomega.HaveKeyWithValue("version", "v2"))
+}
+
+func TestIstioRouterRulesOfAnotherExperiment(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := newTestContext()
+	instance := newIstioTestExperiment("reviews-v2-rollout", "reviews")
+	objs := append(newIstioTestObjects("reviews", false),
+		NewDestinationRule("reviews", "reviews-v3-rollout", "bookinfo").WithProgressingLabel().Build(),
+		NewVirtualService("reviews", "reviews-v3-rollout", "bookinfo").WithProgressingLabel().Build())
+	r := newFakeReconciler(g, objs...)
+
+	// rules controlled by another experiment are neither taken over nor snapshotted
+	g.Expect(newIstioRouter(r).Init(ctx, instance)).NotTo(gomega.Succeed())
+	g.Expect(instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionRoutingRulesReady).Status).
+		To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(instance.Status.RoutingSnapshot).To(gomega.BeNil())
+	dr, vs := getIstioTestRules(g, r, "reviews")
+	g.Expect(dr.GetLabels()).To(gomega.HaveKeyWithValue(experimentLabel, "reviews-v3-rollout"))
+	g.Expect(vs.GetLabels()).To(gomega.HaveKeyWithValue(experimentLabel, "reviews-v3-rollout"))
+	g.Expect(dr.Spec.Subsets).To(gomega.BeEmpty())
+}
+
+func TestIstioRouterConcurrentExperiments(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := newTestContext()
+	reviews := newIstioTestExperiment("reviews-v2-rollout", "reviews")
+	ratings := newIstioTestExperiment("ratings-v2-rollout", "ratings")
+	r := newFakeReconciler(g, append(newIstioTestObjects("reviews", false), newIstioTestObjects("ratings", false)...)...)
+
+	stepSize := 20.0
+	for _, instance := range []*iter8v1alpha1.Experiment{reviews, ratings} {
+		instance.Spec.TrafficControl.TrafficStepSize = &stepSize
+		router := newIstioRouter(r)
+		g.Expect(router.Init(ctx, instance)).To(gomega.Succeed())
+		g.Expect(r.progressExperiment(ctx, instance, router)).To(gomega.Succeed())
+	}
+	ratingsDR, _ := getIstioTestRules(g, r, "ratings")
+
+	// one experiment is finalized while the reconcile of the other one is under way: each router only
+	// works on the rules and targets of its own experiment
+	router := newIstioRouter(r)
+	g.Expect(router.Init(ctx, ratings)).To(gomega.Succeed())
+	g.Expect(newIstioRouter(r).Finalize(ctx, reviews)).To(gomega.Succeed())
+	g.Expect(r.progressExperiment(ctx, ratings, router)).To(gomega.Succeed())
+
+	dr, vs := getIstioTestRules(g, r, "reviews")
+	g.Expect(vs.GetLabels()).NotTo(gomega.HaveKey(experimentLabel))
+	g.Expect(getWeight(Stable, vs)).To(gomega.Equal(int32(100)))
+	g.Expect(dr.Spec.Subsets).To(gomega.HaveLen(1))
+	g.Expect(dr.Spec.Subsets[0].Labels).To(gomega.HaveKeyWithValue("version", "v1"))
+
+	dr, vs = getIstioTestRules(g, r, "ratings")
+	g.Expect(dr).To(gomega.Equal(ratingsDR))
+	g.Expect(vs.GetLabels()).To(gomega.HaveKeyWithValue(experimentLabel, ratings.Name))
+	g.Expect(getWeight(candidateSubsets(ratings)[0], vs)).To(gomega.Equal(int32(40)))
+	baseline, candidates := router.GetWeights(ratings)
+	g.Expect(baseline).To(gomega.Equal(60))
+	g.Expect(candidates).To(gomega.Equal([]int{40}))
+}
@@ -0,0 +1,58 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// checkPause pauses or resumes the experiment as requested by Spec.Action.
+// It returns true while the experiment is paused, in which case no iteration must be run.
+func (r *ExperimentReconciler) checkPause(context context.Context, instance *iter8v1alpha1.Experiment) (bool, error) {
+	paused := instance.Status.IsPaused()
+
+	if instance.Spec.Action == iter8v1alpha1.ActionPause {
+		if paused {
+			return true, nil
+		}
+		r.MarkExperimentPaused(context, instance, "Paused at iteration %d, baseline: %d, candidate: %d",
+			instance.Status.CurrentIteration, instance.Status.TrafficSplit.Baseline, instance.Status.TrafficSplit.Candidate)
+		return true, nil
+	}
+
+	if !paused {
+		return false, nil
+	}
+
+	resumeIteration(instance, time.Now())
+	r.MarkExperimentResumed(context, instance, "Resumed at iteration %d", instance.Status.CurrentIteration)
+	return false, nil
+}
+
+// resumeIteration moves the last increment time forward by the time spent paused,
+// so that the pause does not count against the interval of the current iteration
+func resumeIteration(instance *iter8v1alpha1.Experiment, now time.Time) {
+	pausedAt := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionPaused).LastTransitionTime.Inner
+	if pausedAt.IsZero() || now.Before(pausedAt.Time) {
+		return
+	}
+	instance.Status.LastIncrementTime = metav1.NewTime(instance.Status.LastIncrementTime.Add(now.Sub(pausedAt.Time)))
+}
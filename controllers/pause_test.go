@@ -0,0 +1,45 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResumeIteration(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Status.InitializeConditions()
+
+	lastIncrement := time.Now().Add(-30 * time.Second)
+	instance.Status.LastIncrementTime = metav1.NewTime(lastIncrement)
+	instance.Status.MarkExperimentPaused("ExperimentPaused", "")
+	g.Expect(instance.Status.IsPaused()).To(gomega.BeTrue())
+	g.Expect(instance.Status.Phase).To(gomega.Equal(iter8v1alpha1.PhasePause))
+
+	pausedAt := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionPaused).LastTransitionTime.Inner.Time
+	resumeIteration(instance, pausedAt.Add(10*time.Minute))
+	g.Expect(instance.Status.LastIncrementTime.Time.Equal(lastIncrement.Add(10 * time.Minute))).To(gomega.BeTrue())
+
+	instance.Status.MarkExperimentResumed("ExperimentResumed", "")
+	g.Expect(instance.Status.IsPaused()).To(gomega.BeFalse())
+	g.Expect(instance.Status.Phase).To(gomega.Equal(iter8v1alpha1.PhaseProgressing))
+}
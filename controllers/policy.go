@@ -0,0 +1,63 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// policyRecheckInterval is how often an experiment held by a policy violation is reevaluated
+const policyRecheckInterval = time.Minute
+
+// checkPolicies evaluates the experiment policies applying to the namespace of the experiment.
+// It returns true while the experiment violates a policy, in which case no iteration must be run.
+// An assessment given by the user ends the experiment regardless of the policies.
+func (r *ExperimentReconciler) checkPolicies(context context.Context, instance *iter8v1alpha1.Experiment) (bool, error) {
+	policies := &iter8v1alpha1.ExperimentPolicyList{}
+	if err := r.List(context, policies); err != nil {
+		return true, err
+	}
+
+	violations := field.ErrorList{}
+	for i := range policies.Items {
+		if policy := &policies.Items[i]; policy.AppliesTo(instance.Namespace) {
+			violations = append(violations, policy.Violations(instance)...)
+		}
+	}
+
+	violated := instance.Status.IsPolicyViolated()
+	if len(violations) > 0 {
+		if instance.Spec.Assessment != iter8v1alpha1.AssessmentNull {
+			return false, nil
+		}
+		if violated {
+			return true, nil
+		}
+		r.MarkPolicyViolated(context, instance, "%s", violations.ToAggregate().Error())
+		return true, nil
+	}
+
+	if !violated {
+		return false, nil
+	}
+	r.MarkPolicyCompliant(context, instance, "Experiment complies with the experiment policies")
+	return false, nil
+}
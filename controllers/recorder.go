@@ -87,6 +87,102 @@ func (r *ExperimentReconciler) MarkExperimentFailed(context context.Context, ins
 	r.eventRecorder.Eventf(instance, corev1.EventTypeWarning, reason, messageFormat, messageA...)
 }
 
+func (r *ExperimentReconciler) MarkExperimentPaused(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "ExperimentPaused"
+	instance.Status.MarkExperimentPaused(reason, messageFormat, messageA...)
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.recordNormalEvent(true, instance, reason, messageFormat, messageA...)
+}
+
+func (r *ExperimentReconciler) MarkExperimentResumed(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "ExperimentResumed"
+	instance.Status.MarkExperimentResumed(reason, messageFormat, messageA...)
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.recordNormalEvent(true, instance, reason, messageFormat, messageA...)
+}
+
+func (r *ExperimentReconciler) MarkExperimentRerun(context context.Context, instance *iter8v1alpha1.Experiment,
+	token string) {
+	reason := "ExperimentRerun"
+	instance.Status.Rerun(token)
+	Logger(context).Info(reason + ", " + token)
+	r.recordNormalEvent(true, instance, reason, "Previous run archived, rerun %s started", token)
+}
+
+func (r *ExperimentReconciler) MarkAwaitingApproval(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "AwaitingApproval"
+	instance.Status.MarkAwaitingApproval(reason, messageFormat, messageA...)
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.recordNormalEvent(true, instance, reason, messageFormat, messageA...)
+}
+
+func (r *ExperimentReconciler) MarkApproved(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "Approved"
+	instance.Status.MarkApproved(reason, messageFormat, messageA...)
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.recordNormalEvent(true, instance, reason, messageFormat, messageA...)
+}
+
+func (r *ExperimentReconciler) MarkPolicyViolated(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "PolicyViolated"
+	instance.Status.MarkPolicyViolated(reason, messageFormat, messageA...)
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.eventRecorder.Eventf(instance, corev1.EventTypeWarning, reason, messageFormat, messageA...)
+}
+
+func (r *ExperimentReconciler) MarkPolicyCompliant(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "PolicyCompliant"
+	instance.Status.MarkPolicyCompliant(reason, messageFormat, messageA...)
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.recordNormalEvent(true, instance, reason, messageFormat, messageA...)
+}
+
+func (r *ExperimentReconciler) MarkRoutingDrifted(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "RoutingDrifted"
+	instance.Status.MarkRoutingDrifted(reason, messageFormat, messageA...)
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.eventRecorder.Eventf(instance, corev1.EventTypeWarning, reason, messageFormat, messageA...)
+}
+
+func (r *ExperimentReconciler) MarkRoutingRestored(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "RoutingRestored"
+	instance.Status.MarkRoutingRestored(reason, messageFormat, messageA...)
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.recordNormalEvent(true, instance, reason, messageFormat, messageA...)
+}
+
+// recordFailureBudgetExhausted records the action taken once the analytics service failed beyond the failure policy
+func (r *ExperimentReconciler) recordFailureBudgetExhausted(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "FailureBudgetExhausted"
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.eventRecorder.Eventf(instance, corev1.EventTypeWarning, reason, messageFormat, messageA...)
+}
+
+// recordRoutingDrift records a change of the routing rules the experiment did not pause for
+func (r *ExperimentReconciler) recordRoutingDrift(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "RoutingDrifted"
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.eventRecorder.Eventf(instance, corev1.EventTypeWarning, reason, messageFormat, messageA...)
+}
+
+func (r *ExperimentReconciler) MarkTemplateError(context context.Context, instance *iter8v1alpha1.Experiment,
+	messageFormat string, messageA ...interface{}) {
+	reason := "TemplateError"
+	instance.Status.MarkTemplateError(reason, messageFormat, messageA...)
+	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
+	r.eventRecorder.Eventf(instance, corev1.EventTypeWarning, reason, messageFormat, messageA...)
+}
+
 func (r *ExperimentReconciler) MarkSyncMetricsError(context context.Context, instance *iter8v1alpha1.Experiment,
 	messageFormat string, messageA ...interface{}) {
 	reason := "SyncMetricsError"
@@ -112,12 +208,14 @@ func (r *ExperimentReconciler) MarkRoutingRulesError(context context.Context, in
 }
 
 func (r *ExperimentReconciler) MarkRoutingRulesReady(context context.Context, instance *iter8v1alpha1.Experiment,
-	messageFormat string, messageA ...interface{}) {
+	messageFormat string, messageA ...interface{}) bool {
 	reason := "RoutingRulesReady"
 	Logger(context).Info(reason + ", " + fmt.Sprintf(messageFormat, messageA...))
-	if instance.Status.MarkRoutingRulesReady() {
+	value := instance.Status.MarkRoutingRulesReady()
+	if value {
 		r.recordNormalEvent(true, instance, reason, messageFormat, messageA...)
 	}
+	return value
 }
 
 func (r *ExperimentReconciler) recordNormalEvent(broadcast bool, instance *iter8v1alpha1.Experiment, reason string,
@@ -0,0 +1,53 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// rerunAnnotation reruns a completed experiment each time it is set to a new value, e.g. a pipeline run id
+const rerunAnnotation = "iter8.tools/rerun"
+
+// checkRerun starts a new run of the completed experiment against the current targets when the rerun
+// annotation is set to a value not handled yet. It returns true if a new run was started.
+func (r *ExperimentReconciler) checkRerun(context context.Context, instance *iter8v1alpha1.Experiment) (bool, error) {
+	annotations := instance.GetAnnotations()
+	token := annotations[rerunAnnotation]
+	if token == "" || token == instance.Status.LastRerun {
+		return false, nil
+	}
+
+	// The assessment which ended the previous run must not end the new one,
+	// nor may the approval given in the previous run approve a gate of the new one
+	_, approved := annotations[approveAnnotation]
+	if instance.Spec.Assessment != iter8v1alpha1.AssessmentNull || approved {
+		status := instance.Status.DeepCopy()
+		instance.Spec.Assessment = iter8v1alpha1.AssessmentNull
+		delete(annotations, approveAnnotation)
+		delete(annotations, approverAnnotation)
+		instance.SetAnnotations(annotations)
+		if err := r.Update(context, instance); err != nil {
+			return false, err
+		}
+		instance.Status = *status
+	}
+
+	r.MarkExperimentRerun(context, instance, token)
+	return true, nil
+}
@@ -0,0 +1,67 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestCheckRerun(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	ctx := newTestContext()
+	instance := newIstioTestExperiment("reviews-v2-rollout", "reviews")
+	instance.Spec.TrafficControl.ApprovalGates = []int{10}
+	instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideSuccess
+	instance.Status.Approvals = []iter8v1alpha1.Approval{{Gate: 10, Approver: "jason"}}
+	instance.Status.MarkExperimentCompleted()
+	instance.SetAnnotations(map[string]string{
+		rerunAnnotation:    "1",
+		approveAnnotation:  "10",
+		approverAnnotation: "jason",
+	})
+	r := newFakeReconciler(g, instance)
+
+	rerun, err := r.checkRerun(ctx, instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(rerun).To(gomega.BeTrue())
+	g.Expect(instance.Status.LastRerun).To(gomega.Equal("1"))
+	g.Expect(instance.Status.Approvals).To(gomega.BeEmpty())
+
+	// the approval of the previous run is dropped along with its assessment
+	stored := &iter8v1alpha1.Experiment{}
+	g.Expect(r.Get(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, stored)).To(gomega.Succeed())
+	g.Expect(stored.Spec.Assessment).To(gomega.Equal(iter8v1alpha1.AssessmentNull))
+	g.Expect(stored.GetAnnotations()).NotTo(gomega.HaveKey(approveAnnotation))
+	g.Expect(stored.GetAnnotations()).NotTo(gomega.HaveKey(approverAnnotation))
+	g.Expect(stored.GetAnnotations()).To(gomega.HaveKeyWithValue(rerunAnnotation, "1"))
+
+	// so the gate of the new run stays held until approved again
+	instance.Status.InitializeConditions()
+	r.MarkAwaitingApproval(ctx, instance, "Traffic held at %d%% until approved", 10)
+	held, err := r.checkApproval(ctx, instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(held).To(gomega.BeTrue())
+	g.Expect(instance.Status.IsApproved(10)).To(gomega.BeFalse())
+
+	// the same token does not start another run
+	rerun, err = r.checkRerun(ctx, instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(rerun).To(gomega.BeFalse())
+}
@@ -0,0 +1,309 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// Router drives the traffic of the target service of an experiment through one routing backend.
+// A router is created for each reconcile and holds the routing objects and targets it fetched.
+type Router interface {
+	// Init resolves the targets of the experiment and takes over the routing of the target service,
+	// snapshotting the routing it starts from. It may return errSnapshotTaken to take over on the next pass
+	Init(context context.Context, instance *iter8v1alpha1.Experiment) error
+
+	// Targets returns the baseline and the candidates as given to the analytics service, in the order of
+	// TargetService.GetCandidates(). It does not change the routing
+	Targets(context context.Context, instance *iter8v1alpha1.Experiment) (interface{}, []interface{}, error)
+
+	// Completed tells whether the iterations of the experiment are exhausted
+	Completed(instance *iter8v1alpha1.Experiment) bool
+
+	// GetWeights returns the traffic percentages routed to the baseline and to each candidate
+	GetWeights(instance *iter8v1alpha1.Experiment) (int, []int)
+
+	// SetWeights routes the given traffic percentages to the baseline and to each candidate
+	SetWeights(context context.Context, instance *iter8v1alpha1.Experiment, baseline int, candidates []int) error
+
+	// Promote settles the routing at the end of a successful experiment, as given by OnSuccess
+	// and Status.Winner, and hands the target service back
+	Promote(context context.Context, instance *iter8v1alpha1.Experiment) error
+
+	// Rollback sends the traffic back as it was before the experiment, or else to the baseline,
+	// at the end of a failed or aborted experiment, and hands the target service back.
+	// It records the traffic split it restores when it differs from all to the baseline
+	Rollback(context context.Context, instance *iter8v1alpha1.Experiment) error
+
+	// Finalize rolls back an experiment deleted before completion.
+	// The routing is fetched again since the experiment may not have been reconciled by this process
+	Finalize(context context.Context, instance *iter8v1alpha1.Experiment) error
+}
+
+// errSnapshotTaken is returned by Init when it snapshotted the routing it starts from. The routing is taken over
+// on the next pass, once the snapshot is persisted with the status, so that it can always be restored
+var errSnapshotTaken = fmt.Errorf("RoutingSnapshotTaken")
+
+// routerFactory creates a router for one reconcile
+type routerFactory func(r *ExperimentReconciler) Router
+
+// routers are the routing backends of each target kind, by routing provider
+var routers = map[string]map[string]routerFactory{}
+
+// defaultRoutingProviders are the routing providers of experiments which do not set one, by target kind
+var defaultRoutingProviders = map[string]string{
+	KubernetesService:      iter8v1alpha1.RoutingProviderIstio,
+	KnativeServiceV1Alpha1: iter8v1alpha1.RoutingProviderKnative,
+}
+
+// registerRouter makes a routing backend available to the experiments on targets of the given kind
+// which select the given routing provider
+func registerRouter(apiVersion, provider string, factory routerFactory) {
+	if routers[apiVersion] == nil {
+		routers[apiVersion] = map[string]routerFactory{}
+	}
+	routers[apiVersion][provider] = factory
+}
+
+// newRouter creates the router of the experiment from the kind of its target service and its routing provider
+func (r *ExperimentReconciler) newRouter(instance *iter8v1alpha1.Experiment) (Router, error) {
+	apiVersion := instance.Spec.TargetService.APIVersion
+	backends, ok := routers[apiVersion]
+	if !ok {
+		return nil, fmt.Errorf("UnsupportedAPIVersion: %s", apiVersion)
+	}
+
+	provider := instance.Spec.RoutingProvider
+	if provider == "" {
+		provider = defaultRoutingProviders[apiVersion]
+	}
+	factory, ok := backends[provider]
+	if !ok {
+		return nil, fmt.Errorf("UnsupportedRoutingProvider: %s for %s", provider, apiVersion)
+	}
+	return factory(r), nil
+}
+
+// syncRouting runs the experiment through its router
+func (r *ExperimentReconciler) syncRouting(context context.Context, instance *iter8v1alpha1.Experiment,
+	router Router) (reconcile.Result, error) {
+	log := Logger(context)
+
+	if err := router.Init(context, instance); err != nil {
+		if err == errSnapshotTaken {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		// retry in 5 secs
+		log.Info("retry in 5 secs", "err", err)
+		return reconcile.Result{RequeueAfter: 5 * time.Second}, nil
+	}
+
+	baseline, candidates := router.GetWeights(instance)
+	drifted, err := r.checkDrift(context, instance, baseline, candidates, func(baseline int, candidates []int) error {
+		return router.SetWeights(context, instance, baseline, candidates)
+	})
+	if drifted || err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if router.Completed(instance) || instance.Spec.Assessment != iter8v1alpha1.AssessmentNull {
+		return reconcile.Result{}, r.completeExperiment(context, instance, router)
+	}
+
+	now := time.Now()
+	traffic := instance.Spec.TrafficControl
+	interval, err := traffic.GetDwellDuration(instance.Status.TrafficSplit.Candidate)
+	if err != nil {
+		// the admission webhook validates the dwells of the experiment, and applyTemplate those of its template
+		log.Info("invalid dwell", "err", err)
+		return reconcile.Result{}, err
+	}
+	if !now.After(instance.Status.LastIncrementTime.Add(interval)) && !withRecheckRequirement(instance) {
+		return reconcile.Result{RequeueAfter: instance.Status.LastIncrementTime.Add(interval).Sub(now)}, nil
+	}
+
+	// hold the retry of a failed analysis until its backoff is over
+	if retryIn := analyticsRetryIn(instance, now); retryIn > 0 {
+		return reconcile.Result{RequeueAfter: retryIn}, nil
+	}
+
+	if err := r.progressExperiment(context, instance, router); err != nil {
+		if instance.Status.AnalyticsFailures > 0 {
+			// the analysis failed: retry with backoff
+			log.Info("analytics failure", "failures", instance.Status.AnalyticsFailures, "err", err)
+			return analyticsRetry(instance), nil
+		}
+		// TODO: may need a better handling method
+		// retry in 5 sec
+		log.Info("retry in 5 secs", "err", err)
+		return reconcile.Result{RequeueAfter: 5 * time.Second}, err
+	}
+
+	if router.Completed(instance) {
+		return reconcile.Result{Requeue: true}, nil
+	}
+
+	// Next iteration
+	interval, err = traffic.GetDwellDuration(instance.Status.TrafficSplit.Candidate)
+	return reconcile.Result{RequeueAfter: interval}, err
+}
+
+// progressExperiment runs one iteration of the experiment: it computes the next traffic split,
+// with the analytics service unless the strategy does without, and routes it
+func (r *ExperimentReconciler) progressExperiment(context context.Context, instance *iter8v1alpha1.Experiment,
+	router Router) error {
+	log := Logger(context)
+	traffic := instance.Spec.TrafficControl
+
+	_, rolloutPercent := router.GetWeights(instance)
+	total := 0
+	for _, percent := range rolloutPercent {
+		total += percent
+	}
+
+	var newRolloutPercent []int
+	if iter8v1alpha1.StrategyIncrementWithoutCheck == getStrategy(instance) {
+		newRolloutPercent = splitTraffic(incrementTraffic(&traffic, total, len(rolloutPercent)), len(rolloutPercent))
+	} else {
+		baseline, candidates, err := router.Targets(context, instance)
+		if err != nil {
+			recordIteration(instance, err)
+			return err
+		}
+
+		percents, err := r.analyzeCandidates(context, instance, baseline, candidates)
+		if err != nil {
+			recordIteration(instance, err)
+			if r.checkFailureBudget(context, instance) == iter8v1alpha1.FailureActionRollback {
+				// the outcome of the last analysis must not promote a candidate
+				instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+				return r.abortExperiment(context, instance, router, "Analytics failures, Traffic: AllToBaseline.")
+			}
+			return err
+		}
+
+		if instance.Status.AssessmentSummary.AbortExperiment {
+			return r.abortExperiment(context, instance, router, "Aborted, Traffic: AllToBaseline.")
+		}
+		newRolloutPercent = capToNextStep(&traffic, total, percents)
+	}
+	newRolloutPercent = capToGate(instance, newRolloutPercent)
+
+	// No traffic is shifted while the candidate is assessed on mirrored traffic
+	if traffic.IsMirroring(instance.Status.CurrentIteration) {
+		newRolloutPercent = rolloutPercent
+	}
+
+	needUpdate := false
+	newTotal := 0
+	for i, percent := range newRolloutPercent {
+		needUpdate = needUpdate || percent != rolloutPercent[i]
+		newTotal += percent
+	}
+	if needUpdate {
+		log.Info("update traffic", "rolloutPercent", newRolloutPercent)
+		if err := router.SetWeights(context, instance, 100-newTotal, newRolloutPercent); err != nil {
+			r.MarkRoutingRulesError(context, instance, "Fail to update traffic: %v", err)
+			recordIteration(instance, err)
+			return err
+		}
+		r.MarkRoutingRulesReady(context, instance, "")
+	}
+
+	baseline, candidates := router.GetWeights(instance)
+	setTrafficSplit(instance, baseline, candidates)
+	recordIteration(instance, nil)
+	instance.Status.CurrentIteration++
+	instance.Status.LastIncrementTime = metav1.NewTime(time.Now())
+
+	r.MarkExperimentProgress(context, instance, needUpdate, "Iteration %d Completed, baseline: %d, candidate: %d",
+		instance.Status.CurrentIteration, instance.Status.TrafficSplit.Baseline, instance.Status.TrafficSplit.Candidate)
+	r.checkGate(context, instance, candidates)
+	return nil
+}
+
+// abortExperiment rolls back to the baseline and fails the experiment
+func (r *ExperimentReconciler) abortExperiment(context context.Context, instance *iter8v1alpha1.Experiment,
+	router Router, message string) error {
+	Logger(context).Info("ExperimentAborted. Rollback to Baseline.")
+	setTrafficSplit(instance, 100, make([]int, len(instance.Spec.TargetService.GetCandidates())))
+	if err := router.Rollback(context, instance); err != nil {
+		r.MarkRoutingRulesError(context, instance, "Fail to roll back: %v", err)
+		recordIteration(instance, err)
+		return err
+	}
+	recordIteration(instance, nil)
+	r.MarkExperimentFailed(context, instance, "%s", message)
+	return nil
+}
+
+// completeExperiment finishes the experiment when iterations are exhausted or an assessment is given
+func (r *ExperimentReconciler) completeExperiment(context context.Context, instance *iter8v1alpha1.Experiment,
+	router Router) error {
+	succeeded := experimentSucceeded(instance)
+	if succeeded {
+		instance.Status.Winner = selectWinner(instance)
+	}
+
+	// The router may record the split it actually leaves, e.g. a restored snapshot
+	setFinalTrafficSplit(instance, succeeded)
+	if succeeded {
+		if err := router.Promote(context, instance); err != nil {
+			r.MarkRoutingRulesError(context, instance, "Fail to clean up: %v", err)
+			return err
+		}
+	} else if err := router.Rollback(context, instance); err != nil {
+		r.MarkRoutingRulesError(context, instance, "Fail to clean up: %v", err)
+		return err
+	}
+
+	if succeeded {
+		r.MarkExperimentSucceeded(context, instance, "%s", successMsg(instance))
+	} else {
+		r.MarkExperimentFailed(context, instance, "%s", failureMsg(instance))
+	}
+	return nil
+}
+
+// setFinalTrafficSplit records the traffic split the experiment ends with
+func setFinalTrafficSplit(instance *iter8v1alpha1.Experiment, succeeded bool) {
+	candidates := instance.Spec.TargetService.GetCandidates()
+	if !succeeded {
+		setTrafficSplit(instance, 100, make([]int, len(candidates)))
+		return
+	}
+
+	switch instance.Spec.TrafficControl.GetOnSuccess() {
+	case "baseline":
+		setTrafficSplit(instance, 100, make([]int, len(candidates)))
+	case "candidate":
+		split := make([]int, len(candidates))
+		for i, candidate := range candidates {
+			if candidate == instance.Status.Winner {
+				split[i] = 100
+			}
+		}
+		setTrafficSplit(instance, 0, split)
+	case "both":
+	}
+}
@@ -0,0 +1,83 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+)
+
+func TestNewRouter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	r := &ExperimentReconciler{}
+	instance := &iter8v1alpha1.Experiment{}
+
+	// each target kind has a default routing provider
+	instance.Spec.TargetService.APIVersion = KubernetesService
+	router, err := r.newRouter(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(router).To(gomega.BeAssignableToTypeOf(&istioRouter{}))
+
+	instance.Spec.RoutingProvider = iter8v1alpha1.RoutingProviderSMI
+	router, err = r.newRouter(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(router).To(gomega.BeAssignableToTypeOf(&smiRouter{}))
+
+	instance.Spec.RoutingProvider = iter8v1alpha1.RoutingProviderGateway
+	router, err = r.newRouter(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(router).To(gomega.BeAssignableToTypeOf(&gatewayRouter{}))
+	instance.Spec.RoutingProvider = ""
+
+	instance.Spec.TargetService.APIVersion = KnativeServiceV1Alpha1
+	router, err = r.newRouter(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(router).To(gomega.BeAssignableToTypeOf(&knativeRouter{}))
+
+	// a provider must be registered for the target kind
+	instance.Spec.RoutingProvider = iter8v1alpha1.RoutingProviderIstio
+	_, err = r.newRouter(instance)
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	instance.Spec.TargetService.APIVersion = "apps/v1"
+	_, err = r.newRouter(instance)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestRouterCompleted(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	r := &ExperimentReconciler{}
+	maxIterations := 3
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Spec.TrafficControl.MaxIterations = &maxIterations
+
+	istio, knative := newIstioRouter(r), newKnativeRouter(r)
+
+	// Istio experiments run one more iteration than Knative experiments
+	instance.Status.CurrentIteration = maxIterations - 1
+	g.Expect(istio.Completed(instance)).To(gomega.BeFalse())
+	g.Expect(knative.Completed(instance)).To(gomega.BeFalse())
+
+	instance.Status.CurrentIteration = maxIterations
+	g.Expect(istio.Completed(instance)).To(gomega.BeFalse())
+	g.Expect(knative.Completed(instance)).To(gomega.BeTrue())
+
+	instance.Status.CurrentIteration = maxIterations + 1
+	g.Expect(istio.Completed(instance)).To(gomega.BeTrue())
+	g.Expect(knative.Completed(instance)).To(gomega.BeTrue())
+}
@@ -16,13 +16,21 @@ limitations under the License.
 package experiment
 
 import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
 	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
 
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istioclient "istio.io/client-go/pkg/clientset/versioned"
 
 	appsv1 "k8s.io/api/apps/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 type IstioRoutingRules struct {
@@ -30,6 +38,94 @@ type IstioRoutingRules struct {
 	VirtualService  *v1alpha3.VirtualService
 }
 
+// GetRoutingRules looks up the destination rule and virtual service defined for the target service
+func (r *IstioRoutingRules) GetRoutingRules(instance *iter8v1alpha1.Experiment, ic istioclient.Interface) error {
+	serviceName := instance.Spec.TargetService.Name
+	serviceNamespace := getServiceNamespace(instance)
+	listOptions := metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(map[string]string{experimentHost: serviceName}).String(),
+	}
+
+	drl, err := ic.NetworkingV1alpha3().DestinationRules(serviceNamespace).List(listOptions)
+	if err != nil {
+		return err
+	}
+	for i := range drl.Items {
+		dr := &drl.Items[i]
+		if exp, ok := dr.GetLabels()[experimentLabel]; ok && exp != instance.GetName() {
+			return fmt.Errorf("DestinationRule %s is controlled by experiment %s", dr.GetName(), exp)
+		}
+		if r.DestinationRule != nil {
+			return fmt.Errorf("Multiple DestinationRules found for host %s", serviceName)
+		}
+		r.DestinationRule = dr
+	}
+
+	vsl, err := ic.NetworkingV1alpha3().VirtualServices(serviceNamespace).List(listOptions)
+	if err != nil {
+		return err
+	}
+	for i := range vsl.Items {
+		vs := &vsl.Items[i]
+		if exp, ok := vs.GetLabels()[experimentLabel]; ok && exp != instance.GetName() {
+			return fmt.Errorf("VirtualService %s is controlled by experiment %s", vs.GetName(), exp)
+		}
+		if r.VirtualService != nil {
+			return fmt.Errorf("Multiple VirtualServices found for host %s", serviceName)
+		}
+		r.VirtualService = vs
+	}
+
+	if (r.DestinationRule == nil) != (r.VirtualService == nil) {
+		return fmt.Errorf("DestinationRule and VirtualService for host %s must be provided together", serviceName)
+	}
+
+	return nil
+}
+
+// InitRoutingRules creates the routing rules of the experiment when the target service has none
+func (r *IstioRoutingRules) InitRoutingRules(instance *iter8v1alpha1.Experiment, ic istioclient.Interface) error {
+	serviceName := instance.Spec.TargetService.Name
+	serviceNamespace := getServiceNamespace(instance)
+	subsets := candidateSubsets(instance)
+
+	dr := NewDestinationRule(serviceName, instance.GetName(), serviceNamespace).
+		WithProgressingLabel().
+		WithInitLabel().
+		Build()
+	dr, err := ic.NetworkingV1alpha3().DestinationRules(serviceNamespace).Create(dr)
+	if err != nil {
+		return err
+	}
+	r.DestinationRule = dr
+
+	vs := NewVirtualService(serviceName, instance.GetName(), serviceNamespace).
+		WithProgressingLabel().
+		WithInitLabel().
+		WithRolloutPercent(serviceName, serviceNamespace, subsets, make([]int32, len(subsets))).
+		WithMatchRoutes(serviceName, serviceNamespace, matchRoutes(instance)).
+		Build()
+	vs, err = ic.NetworkingV1alpha3().VirtualServices(serviceNamespace).Create(vs)
+	if err != nil {
+		return err
+	}
+	r.VirtualService = vs
+
+	return nil
+}
+
+func (r *IstioRoutingRules) IsEmpty() bool {
+	return r.DestinationRule == nil && r.VirtualService == nil
+}
+
+// IsProgressing tells whether both rules are registered with experiment expName
+func (r *IstioRoutingRules) IsProgressing(expName string) bool {
+	drLabels, vsLabels := r.DestinationRule.GetLabels(), r.VirtualService.GetLabels()
+
+	return drLabels[experimentRole] == Progressing && vsLabels[experimentRole] == Progressing &&
+		drLabels[experimentLabel] == expName && vsLabels[experimentLabel] == expName
+}
+
 func (r *IstioRoutingRules) SetStableLabels() {
 	r.DestinationRule.ObjectMeta.SetLabels(map[string]string{experimentRole: Stable})
 	r.VirtualService.ObjectMeta.SetLabels(map[string]string{experimentRole: Stable})
@@ -50,7 +146,8 @@ func (rules *IstioRoutingRules) IsInit() bool {
 	return drok && vsok
 }
 
-func (r *IstioRoutingRules) StableToProgressing(targets *Targets, expName, serviceNamespace string, ic istioclient.Interface) error {
+func (r *IstioRoutingRules) StableToProgressing(targets *Targets, expName, serviceNamespace string, subsets []string,
+	matches []*networkingv1alpha3.HTTPRoute, ic istioclient.Interface) error {
 	r.DestinationRule = NewDestinationRuleBuilder(r.DestinationRule).
 		WithStableToProgressing(targets.Baseline).
 		WithExperimentRegisterd(expName).
@@ -65,7 +162,8 @@ func (r *IstioRoutingRules) StableToProgressing(targets *Targets, expName, servi
 	}
 
 	r.VirtualService = NewVirtualServiceBuilder(r.VirtualService).
-		WithStableToProgressing(targets.Service.GetName(), serviceNamespace).
+		WithStableToProgressing(targets.Service.GetName(), serviceNamespace, subsets).
+		WithMatchRoutes(targets.Service.GetName(), serviceNamespace, matches).
 		WithExperimentRegisterd(expName).
 		Build()
 	if vs, err := ic.NetworkingV1alpha3().
@@ -79,6 +177,27 @@ func (r *IstioRoutingRules) StableToProgressing(targets *Targets, expName, servi
 	return nil
 }
 
+// UpdateSubsets adds the baseline and candidate subsets to the destination rule if missing
+func (r *IstioRoutingRules) UpdateSubsets(targets *Targets, subsets []string, ic istioclient.Interface) error {
+	update := updateSubset(r.DestinationRule, targets.Baseline, Baseline)
+	for i, subset := range subsets {
+		update = updateSubset(r.DestinationRule, targets.Candidates[i], subset) || update
+	}
+	if !update {
+		return nil
+	}
+
+	if dr, err := ic.NetworkingV1alpha3().
+		DestinationRules(r.DestinationRule.Namespace).
+		Update(r.DestinationRule); err != nil {
+		return err
+	} else {
+		r.DestinationRule = dr
+	}
+
+	return nil
+}
+
 func (r *IstioRoutingRules) DeleteAll(ic istioclient.Interface) (err error) {
 	if err = ic.NetworkingV1alpha3().DestinationRules(r.DestinationRule.Namespace).
 		Delete(r.DestinationRule.Name, &metav1.DeleteOptions{}); err != nil {
@@ -103,11 +222,21 @@ func (r *IstioRoutingRules) Cleanup(instance *iter8v1alpha1.Experiment, targets
 			case "baseline":
 				r.ToStable(targets.Baseline, Baseline, serviceName, serviceName)
 			case "candidate":
-				r.ToStable(targets.Candidate, Candidate, serviceName, serviceName)
+				if i := targets.GetCandidate(instance.Status.Winner); i >= 0 {
+					r.ToStable(targets.Candidates[i], candidateSubsets(instance)[i], serviceName, serviceName)
+				} else {
+					r.ToStable(targets.Baseline, Baseline, serviceName, serviceName)
+				}
 			case "both":
+				r.VirtualService = NewVirtualServiceBuilder(r.VirtualService).RemoveMatchRoutes().RemoveMirror().Build()
 				r.SetStableLabels()
 			}
 
+		} else if snapshot := instance.Status.RoutingSnapshot; snapshot != nil {
+			// rules which existed before the experiment are restored as they were
+			if err = r.Restore(snapshot); err != nil {
+				return
+			}
 		} else {
 			r.ToStable(targets.Baseline, Baseline, serviceName, serviceName)
 		}
@@ -117,6 +246,42 @@ func (r *IstioRoutingRules) Cleanup(instance *iter8v1alpha1.Experiment, targets
 	return
 }
 
+// Snapshot returns the specs of the rules, to be restored with Restore
+func (r *IstioRoutingRules) Snapshot() (*iter8v1alpha1.RoutingSnapshot, error) {
+	vs, err := json.Marshal(&r.VirtualService.Spec)
+	if err != nil {
+		return nil, err
+	}
+	dr, err := json.Marshal(&r.DestinationRule.Spec)
+	if err != nil {
+		return nil, err
+	}
+	return &iter8v1alpha1.RoutingSnapshot{
+		VirtualService:  &runtime.RawExtension{Raw: vs},
+		DestinationRule: &runtime.RawExtension{Raw: dr},
+	}, nil
+}
+
+// Restore sets the specs of the rules back to the snapshot and releases them from the experiment
+func (r *IstioRoutingRules) Restore(snapshot *iter8v1alpha1.RoutingSnapshot) error {
+	if snapshot.VirtualService == nil || snapshot.DestinationRule == nil {
+		return fmt.Errorf("RoutingSnapshotWithoutIstioRules")
+	}
+
+	vs, dr := r.VirtualService.DeepCopy(), r.DestinationRule.DeepCopy()
+	vs.Spec, dr.Spec = networkingv1alpha3.VirtualService{}, networkingv1alpha3.DestinationRule{}
+	if err := json.Unmarshal(snapshot.VirtualService.Raw, &vs.Spec); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(snapshot.DestinationRule.Raw, &dr.Spec); err != nil {
+		return err
+	}
+
+	r.VirtualService = NewVirtualServiceBuilder(vs).WithStableLabel().RemoveExperimentLabel().Build()
+	r.DestinationRule = NewDestinationRuleBuilder(dr).WithStableLabel().RemoveExperimentLabel().Build()
+	return nil
+}
+
 func (r *IstioRoutingRules) ToStable(stableDep *appsv1.Deployment, stableName, serviceName, serviceNamespace string) {
 	r.DestinationRule = NewDestinationRuleBuilder(r.DestinationRule).
 		WithProgressingToStable(stableDep).
@@ -149,17 +314,12 @@ func (r *IstioRoutingRules) UpdateRemoveRules(ic istioclient.Interface) error {
 }
 
 func (r *IstioRoutingRules) GetWeight(subset string) int32 {
-	for _, route := range r.VirtualService.Spec.Http[0].Route {
-		if route.Destination.Subset == subset {
-			return route.Weight
-		}
-	}
-	return 0
+	return getWeight(subset, r.VirtualService)
 }
 
-func (r *IstioRoutingRules) UpdateRolloutPercent(serviceName, serviceNamespace string, w int32, ic istioclient.Interface) error {
+func (r *IstioRoutingRules) UpdateRolloutPercent(serviceName, serviceNamespace string, subsets []string, w []int32, ic istioclient.Interface) error {
 	vs := NewVirtualServiceBuilder(r.VirtualService).
-		WithRolloutPercent(serviceName, serviceNamespace, w).
+		WithRolloutPercent(serviceName, serviceNamespace, subsets, w).
 		Build()
 
 	if vs, err := ic.NetworkingV1alpha3().VirtualServices(vs.Namespace).Update(vs); err != nil {
@@ -170,3 +330,96 @@ func (r *IstioRoutingRules) UpdateRolloutPercent(serviceName, serviceNamespace s
 
 	return nil
 }
+
+// UpdateMirror mirrors percent of the requests to subset, or stops mirroring if subset is empty.
+// The virtual service is only updated on change.
+func (r *IstioRoutingRules) UpdateMirror(serviceName, serviceNamespace, subset string, percent uint32, ic istioclient.Interface) error {
+	http := weightedRoute(r.VirtualService.Spec.Http)
+	if http == nil {
+		return nil
+	}
+	mirrored := ""
+	if http.Mirror != nil {
+		mirrored = http.Mirror.Subset
+	}
+	if mirrored == subset && (subset == "" || http.MirrorPercent.GetValue() == percent) {
+		return nil
+	}
+
+	b := NewVirtualServiceBuilder(r.VirtualService)
+	if subset == "" {
+		b = b.RemoveMirror()
+	} else {
+		b = b.WithMirror(serviceName, serviceNamespace, subset, percent)
+	}
+	if vs, err := ic.NetworkingV1alpha3().VirtualServices(r.VirtualService.Namespace).Update(b.Build()); err != nil {
+		return err
+	} else {
+		r.VirtualService = vs
+	}
+
+	return nil
+}
+
+// matchRoutes builds the HTTP routes sending the user segments of the experiment to their candidates
+func matchRoutes(instance *iter8v1alpha1.Experiment) []*networkingv1alpha3.HTTPRoute {
+	serviceName := instance.Spec.TargetService.Name
+	candidates := instance.Spec.TargetService.GetCandidates()
+	subsets := candidateSubsets(instance)
+
+	var routes []*networkingv1alpha3.HTTPRoute
+	for i, rule := range instance.Spec.TrafficControl.Match {
+		subset := subsets[0]
+		for j, candidate := range candidates {
+			if candidate == rule.Candidate {
+				subset = subsets[j]
+			}
+		}
+
+		match := &networkingv1alpha3.HTTPMatchRequest{}
+		if len(rule.Headers) > 0 || rule.Cookie != nil {
+			match.Headers = map[string]*networkingv1alpha3.StringMatch{}
+		}
+		for _, header := range rule.Headers {
+			match.Headers[strings.ToLower(header.Name)] = headerStringMatch(header)
+		}
+		if rule.Cookie != nil {
+			match.Headers["cookie"] = &networkingv1alpha3.StringMatch{
+				MatchType: &networkingv1alpha3.StringMatch_Regex{
+					Regex: "^(.*?;\\s*)?" + regexp.QuoteMeta(rule.Cookie.Name+"="+rule.Cookie.Value) + "(;.*)?$",
+				},
+			}
+		}
+		if rule.URIPrefix != "" {
+			match.Uri = &networkingv1alpha3.StringMatch{
+				MatchType: &networkingv1alpha3.StringMatch_Prefix{Prefix: rule.URIPrefix},
+			}
+		}
+
+		routes = append(routes, &networkingv1alpha3.HTTPRoute{
+			Name:  fmt.Sprintf("%s%d", matchRoutePrefix, i),
+			Match: []*networkingv1alpha3.HTTPMatchRequest{match},
+			Route: []*networkingv1alpha3.HTTPRouteDestination{
+				{
+					Destination: &networkingv1alpha3.Destination{
+						Host:   serviceName,
+						Subset: subset,
+					},
+					Weight: 100,
+				},
+			},
+		})
+	}
+	return routes
+}
+
+func headerStringMatch(header iter8v1alpha1.HeaderMatch) *networkingv1alpha3.StringMatch {
+	switch {
+	case header.Prefix != "":
+		return &networkingv1alpha3.StringMatch{MatchType: &networkingv1alpha3.StringMatch_Prefix{Prefix: header.Prefix}}
+	case header.Regex != "":
+		return &networkingv1alpha3.StringMatch{MatchType: &networkingv1alpha3.StringMatch_Regex{Regex: header.Regex}}
+	default:
+		return &networkingv1alpha3.StringMatch{MatchType: &networkingv1alpha3.StringMatch_Exact{Exact: header.Exact}}
+	}
+}
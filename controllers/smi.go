@@ -0,0 +1,435 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// TrafficSplitGVK is the SMI TrafficSplit driven by the smi routing provider.
+// It is handled as unstructured content so that no SMI client is needed
+var TrafficSplitGVK = schema.GroupVersionKind{Group: "split.smi-spec.io", Version: "v1alpha2", Kind: "TrafficSplit"}
+
+// TrafficSplitBackend is a backend service of an SMI traffic split
+type TrafficSplitBackend struct {
+	Service string `json:"service"`
+	Weight  int    `json:"weight"`
+}
+
+// SMITrafficSplit is the SMI traffic split of the target service
+type SMITrafficSplit struct {
+	TrafficSplit *unstructured.Unstructured
+}
+
+// GetTrafficSplit looks up the traffic split whose root service is the target service
+func (s *SMITrafficSplit) GetTrafficSplit(context context.Context, instance *iter8v1alpha1.Experiment, c client.Client) error {
+	serviceName := instance.Spec.TargetService.Name
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(TrafficSplitGVK.GroupVersion().WithKind(TrafficSplitGVK.Kind + "List"))
+	if err := c.List(context, list, client.InNamespace(getServiceNamespace(instance))); err != nil {
+		return err
+	}
+
+	for i := range list.Items {
+		split := &list.Items[i]
+		if root, _, _ := unstructured.NestedString(split.Object, "spec", "service"); root != serviceName {
+			continue
+		}
+		if exp, ok := split.GetLabels()[experimentLabel]; ok && exp != instance.GetName() {
+			return fmt.Errorf("TrafficSplit %s is controlled by experiment %s", split.GetName(), exp)
+		}
+		if s.TrafficSplit != nil {
+			return fmt.Errorf("Multiple TrafficSplits found for service %s", serviceName)
+		}
+		s.TrafficSplit = split
+	}
+	return nil
+}
+
+// InitTrafficSplit creates the traffic split of the experiment when the target service has none.
+// All the traffic goes to the baseline
+func (s *SMITrafficSplit) InitTrafficSplit(context context.Context, instance *iter8v1alpha1.Experiment, c client.Client) error {
+	split := &unstructured.Unstructured{}
+	split.SetGroupVersionKind(TrafficSplitGVK)
+	split.SetName(instance.GetName())
+	split.SetNamespace(getServiceNamespace(instance))
+	split.SetLabels(map[string]string{
+		experimentRole:  Progressing,
+		experimentInit:  "True",
+		experimentLabel: instance.GetName(),
+		experimentHost:  instance.Spec.TargetService.Name,
+	})
+	if err := unstructured.SetNestedField(split.Object, instance.Spec.TargetService.Name, "spec", "service"); err != nil {
+		return err
+	}
+	if err := setBackends(split, initialBackends(instance)); err != nil {
+		return err
+	}
+
+	if err := c.Create(context, split); err != nil {
+		return err
+	}
+	s.TrafficSplit = split
+	return nil
+}
+
+func (s *SMITrafficSplit) IsEmpty() bool {
+	return s.TrafficSplit == nil
+}
+
+// IsStable tells whether the traffic split is not controlled by any experiment
+func (s *SMITrafficSplit) IsStable() bool {
+	_, ok := s.TrafficSplit.GetLabels()[experimentLabel]
+	return !ok
+}
+
+// IsProgressing tells whether the traffic split is registered with experiment expName
+func (s *SMITrafficSplit) IsProgressing(expName string) bool {
+	labels := s.TrafficSplit.GetLabels()
+	return labels[experimentRole] == Progressing && labels[experimentLabel] == expName
+}
+
+// IsInit tells whether the traffic split was created by the experiment
+func (s *SMITrafficSplit) IsInit() bool {
+	_, ok := s.TrafficSplit.GetLabels()[experimentInit]
+	return ok
+}
+
+// StableToProgressing registers the traffic split with the experiment and sends all the traffic of the experiment
+// to the baseline
+func (s *SMITrafficSplit) StableToProgressing(context context.Context, instance *iter8v1alpha1.Experiment, c client.Client) error {
+	split := s.TrafficSplit.DeepCopy()
+	labels := split.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[experimentRole] = Progressing
+	labels[experimentLabel] = instance.GetName()
+	labels[experimentHost] = instance.Spec.TargetService.Name
+	split.SetLabels(labels)
+	if err := setTargetBackends(split, instance, experimentServices(instance), initialWeights(instance)); err != nil {
+		return err
+	}
+	return s.update(context, split, c)
+}
+
+// GetWeight returns the weight of the backend service, 0 if it is not a backend
+func (s *SMITrafficSplit) GetWeight(service string) int {
+	backends, _ := getBackends(s.TrafficSplit)
+	for _, backend := range backends {
+		if backend.Service == service {
+			return backend.Weight
+		}
+	}
+	return 0
+}
+
+// UpdateWeights routes the given traffic percentages to the baseline and to each candidate service
+func (s *SMITrafficSplit) UpdateWeights(context context.Context, instance *iter8v1alpha1.Experiment, baseline int, candidates []int,
+	c client.Client) error {
+	split := s.TrafficSplit.DeepCopy()
+	if err := setTargetBackends(split, instance, experimentServices(instance), append([]int{baseline}, candidates...)); err != nil {
+		return err
+	}
+	return s.update(context, split, c)
+}
+
+// Cleanup settles the traffic split at the end of the experiment and releases it. The original backends are restored,
+// with the winner in place of the baseline when the candidate is promoted.
+// A traffic split created by the experiment is removed when the experiment asks to clean up
+func (s *SMITrafficSplit) Cleanup(context context.Context, instance *iter8v1alpha1.Experiment, c client.Client) error {
+	if instance.Spec.CleanUp == iter8v1alpha1.CleanUpDelete && s.IsInit() {
+		return ignoreNotFound(c.Delete(context, s.TrafficSplit))
+	}
+
+	split := s.TrafficSplit.DeepCopy()
+	baseline, stable := instance.Spec.TargetService.Baseline, instance.Spec.TargetService.Baseline
+	succeeded := experimentSucceeded(instance)
+	if succeeded && instance.Spec.TrafficControl.GetOnSuccess() == "candidate" {
+		for _, candidate := range instance.Spec.TargetService.GetCandidates() {
+			if candidate == instance.Status.Winner {
+				stable = candidate
+			}
+		}
+	}
+
+	if succeeded && instance.Spec.TrafficControl.GetOnSuccess() == "both" {
+		// the traffic stays split as it is
+	} else if snapshot := instance.Status.RoutingSnapshot; snapshot != nil {
+		if err := restoreBackends(snapshot, split); err != nil {
+			return err
+		}
+		if stable != baseline {
+			if err := renameBackend(split, baseline, stable); err != nil {
+				return err
+			}
+		}
+	} else if err := setTargetBackends(split, instance, []string{stable}, []int{100}); err != nil {
+		return err
+	}
+
+	labels := split.GetLabels()
+	delete(labels, experimentLabel)
+	delete(labels, experimentInit)
+	labels[experimentRole] = Stable
+	split.SetLabels(labels)
+	return s.update(context, split, c)
+}
+
+// Snapshot returns the backends of the traffic split, to be restored by Cleanup
+func (s *SMITrafficSplit) Snapshot() (*iter8v1alpha1.RoutingSnapshot, error) {
+	backends, err := getBackends(s.TrafficSplit)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(backends)
+	if err != nil {
+		return nil, err
+	}
+	return &iter8v1alpha1.RoutingSnapshot{Backends: &runtime.RawExtension{Raw: raw}}, nil
+}
+
+// update writes the traffic split if it changed
+func (s *SMITrafficSplit) update(context context.Context, split *unstructured.Unstructured, c client.Client) error {
+	if equality.Semantic.DeepEqual(split.Object, s.TrafficSplit.Object) {
+		return nil
+	}
+	if err := c.Update(context, split); err != nil {
+		return err
+	}
+	s.TrafficSplit = split
+	return nil
+}
+
+// initialBackends sends all the traffic to the baseline service
+func initialBackends(instance *iter8v1alpha1.Experiment) []TrafficSplitBackend {
+	backends := []TrafficSplitBackend{{Service: instance.Spec.TargetService.Baseline, Weight: 100}}
+	for _, candidate := range instance.Spec.TargetService.GetCandidates() {
+		backends = append(backends, TrafficSplitBackend{Service: candidate, Weight: 0})
+	}
+	return backends
+}
+
+// getBackends decodes the backends of the traffic split
+func getBackends(split *unstructured.Unstructured) ([]TrafficSplitBackend, error) {
+	backends := []TrafficSplitBackend{}
+	raw, found, err := unstructured.NestedSlice(split.Object, "spec", "backends")
+	if err != nil || !found {
+		return backends, err
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return backends, err
+	}
+	err = json.Unmarshal(data, &backends)
+	return backends, err
+}
+
+// setBackends replaces the backends of the traffic split
+func setBackends(split *unstructured.Unstructured, backends []TrafficSplitBackend) error {
+	raw := make([]interface{}, len(backends))
+	for i, backend := range backends {
+		raw[i] = map[string]interface{}{"service": backend.Service, "weight": int64(backend.Weight)}
+	}
+	return unstructured.SetNestedSlice(split.Object, raw, "spec", "backends")
+}
+
+// setTargetBackends replaces the backends of the traffic split which are the baseline or a candidate
+// with the given services and weights. The other backends are left as they are
+func setTargetBackends(split *unstructured.Unstructured, instance *iter8v1alpha1.Experiment, services []string, weights []int) error {
+	backends, err := getBackends(split)
+	if err != nil {
+		return err
+	}
+	targets := targetNames(instance)
+	out := []TrafficSplitBackend{}
+	for _, backend := range backends {
+		if !targets[backend.Service] {
+			out = append(out, backend)
+		}
+	}
+	for i, service := range services {
+		out = append(out, TrafficSplitBackend{Service: service, Weight: weights[i]})
+	}
+	return setBackends(split, out)
+}
+
+// renameBackend makes the backend service from of the traffic split refer to service to instead
+func renameBackend(split *unstructured.Unstructured, from, to string) error {
+	backends, err := getBackends(split)
+	if err != nil {
+		return err
+	}
+	for i := range backends {
+		if backends[i].Service == from {
+			backends[i].Service = to
+		}
+	}
+	return setBackends(split, backends)
+}
+
+// restoreBackends sets the backends of the traffic split back to the snapshot
+func restoreBackends(snapshot *iter8v1alpha1.RoutingSnapshot, split *unstructured.Unstructured) error {
+	if snapshot.Backends == nil {
+		return fmt.Errorf("RoutingSnapshotWithoutBackends")
+	}
+	backends := []TrafficSplitBackend{}
+	if err := json.Unmarshal(snapshot.Backends.Raw, &backends); err != nil {
+		return err
+	}
+	return setBackends(split, backends)
+}
+
+func init() {
+	registerRouter(KubernetesService, iter8v1alpha1.RoutingProviderSMI, newSMIRouter)
+}
+
+// smiRouter routes the traffic of a Kubernetes service between the baseline and candidate services
+// with an SMI traffic split
+type smiRouter struct {
+	r *ExperimentReconciler
+
+	split    *SMITrafficSplit
+	services *ServiceTargets
+}
+
+func newSMIRouter(r *ExperimentReconciler) Router {
+	return &smiRouter{r: r, split: &SMITrafficSplit{}}
+}
+
+// Init implements Router
+func (s *smiRouter) Init(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	if err := s.split.GetTrafficSplit(context, instance, s.r.Client); err != nil {
+		s.r.MarkRoutingRulesError(context, instance, "Error in getting traffic split: %v", err)
+		return err
+	}
+
+	// The backends are the baseline and candidate services, which must all exist before the traffic split is touched
+	if s.services == nil {
+		services, err := s.r.getServiceTargets(context, instance)
+		if err != nil {
+			return err
+		}
+		s.services = services
+	}
+
+	if s.split.IsEmpty() {
+		if err := s.split.InitTrafficSplit(context, instance, s.r.Client); err != nil {
+			s.r.MarkRoutingRulesError(context, instance, "Error in initializing traffic split: %v", err)
+			return err
+		}
+		s.r.MarkRoutingRulesReady(context, instance, "Init Traffic Split")
+	} else if s.split.IsStable() {
+		// A traffic split which existed before the experiment is restored as it was if the experiment fails
+		if instance.Status.RoutingSnapshot == nil {
+			snapshot, err := s.split.Snapshot()
+			if err != nil {
+				s.r.MarkRoutingRulesError(context, instance, "Fail to snapshot traffic split: %v", err)
+				return err
+			}
+			instance.Status.RoutingSnapshot = snapshot
+			return errSnapshotTaken
+		}
+		if err := s.split.StableToProgressing(context, instance, s.r.Client); err != nil {
+			s.r.MarkRoutingRulesError(context, instance, "Fail to take over traffic split: %v", err)
+			return err
+		}
+		s.r.MarkRoutingRulesReady(context, instance, "")
+	} else if !s.split.IsProgressing(instance.GetName()) {
+		s.r.MarkRoutingRulesError(context, instance, "Traffic split for %s is neither stable nor controlled by this experiment",
+			instance.Spec.TargetService.Name)
+		return fmt.Errorf("UnexpectedTrafficSplit")
+	} else {
+		s.r.MarkRoutingRulesReady(context, instance, "")
+	}
+
+	s.r.MarkTargetsFound(context, instance)
+	return nil
+}
+
+// Targets implements Router. It returns the baseline and candidate services
+func (s *smiRouter) Targets(context context.Context, instance *iter8v1alpha1.Experiment) (interface{}, []interface{}, error) {
+	if s.services == nil {
+		services, err := s.r.getServiceTargets(context, instance)
+		if err != nil {
+			return nil, nil, err
+		}
+		s.services = services
+	}
+	baseline, candidates := s.services.analyticsTargets()
+	return baseline, candidates, nil
+}
+
+// Completed implements Router
+func (s *smiRouter) Completed(instance *iter8v1alpha1.Experiment) bool {
+	return experimentCompleted(instance)
+}
+
+// GetWeights implements Router
+func (s *smiRouter) GetWeights(instance *iter8v1alpha1.Experiment) (int, []int) {
+	names := instance.Spec.TargetService.GetCandidates()
+	candidates := make([]int, len(names))
+	for i, name := range names {
+		candidates[i] = s.split.GetWeight(name)
+	}
+	return s.split.GetWeight(instance.Spec.TargetService.Baseline), candidates
+}
+
+// SetWeights implements Router
+func (s *smiRouter) SetWeights(context context.Context, instance *iter8v1alpha1.Experiment, baseline int, candidates []int) error {
+	return s.split.UpdateWeights(context, instance, baseline, candidates, s.r.Client)
+}
+
+// Promote implements Router
+func (s *smiRouter) Promote(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return s.split.Cleanup(context, instance, s.r.Client)
+}
+
+// Rollback implements Router
+func (s *smiRouter) Rollback(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	return s.split.Cleanup(context, instance, s.r.Client)
+}
+
+// Finalize implements Router
+func (s *smiRouter) Finalize(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	completed := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionExperimentCompleted)
+	if completed == nil || completed.Status == corev1.ConditionTrue {
+		return nil
+	}
+
+	if err := s.split.GetTrafficSplit(context, instance, s.r.Client); err != nil {
+		return err
+	}
+	// a traffic split already released, or driven by another experiment, is left alone
+	if s.split.IsEmpty() || !s.split.IsProgressing(instance.GetName()) {
+		return nil
+	}
+
+	// The traffic split is handed back as after a failed experiment
+	instance.Spec.Assessment = iter8v1alpha1.AssessmentOverrideFailure
+	return ignoreNotFound(s.split.Cleanup(context, instance, s.r.Client))
+}
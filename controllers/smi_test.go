@@ -0,0 +1,90 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestTrafficSplitBackends(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Spec.TargetService = iter8v1alpha1.TargetService{
+		ObjectReference: &corev1.ObjectReference{APIVersion: KubernetesService, Name: "reviews"},
+		Baseline:        "reviews-v1",
+		Candidates:      []string{"reviews-v2", "reviews-v3"},
+	}
+
+	split := &unstructured.Unstructured{}
+	split.SetGroupVersionKind(TrafficSplitGVK)
+	g.Expect(setBackends(split, []TrafficSplitBackend{{Service: "reviews-v0", Weight: 100}})).To(gomega.Succeed())
+
+	// the backends which existed before the experiment are restored from the snapshot
+	s := &SMITrafficSplit{TrafficSplit: split}
+	snapshot, err := s.Snapshot()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	g.Expect(setBackends(split, initialBackends(instance))).To(gomega.Succeed())
+	r := &smiRouter{split: s}
+	baseline, candidates := r.GetWeights(instance)
+	g.Expect(baseline).To(gomega.Equal(100))
+	g.Expect(candidates).To(gomega.Equal([]int{0, 0}))
+
+	g.Expect(restoreBackends(snapshot, split)).To(gomega.Succeed())
+	g.Expect(getBackends(split)).To(gomega.Equal([]TrafficSplitBackend{{Service: "reviews-v0", Weight: 100}}))
+	g.Expect(s.GetWeight("reviews-v1")).To(gomega.BeZero())
+}
+
+func newSMITestExperiment() *iter8v1alpha1.Experiment {
+	strategy, onSuccess := iter8v1alpha1.StrategyIncrementWithoutCheck, "candidate"
+	instance := &iter8v1alpha1.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2-rollout", Namespace: "bookinfo"},
+		Spec: iter8v1alpha1.ExperimentSpec{
+			TargetService: iter8v1alpha1.TargetService{
+				ObjectReference: &corev1.ObjectReference{APIVersion: KubernetesService, Name: "reviews"},
+				Baseline:        "reviews-v1",
+				Candidate:       "reviews-v2",
+			},
+			TrafficControl:  iter8v1alpha1.TrafficControl{Strategy: &strategy, OnSuccess: &onSuccess},
+			RoutingProvider: iter8v1alpha1.RoutingProviderSMI,
+		},
+	}
+	instance.Status.InitializeConditions()
+	return instance
+}
+
+// newSMITestObjects returns the target services, and a traffic split of the target service
+// named "reviews" if backends are given
+func newSMITestObjects(g *gomega.GomegaWithT, backends ...TrafficSplitBackend) []runtime.Object {
+	objs := newTestServices("bookinfo", "reviews", "reviews-v1", "reviews-v2")
+	if len(backends) == 0 {
+		return objs
+	}
+	split := &unstructured.Unstructured{}
+	split.SetGroupVersionKind(TrafficSplitGVK)
+	split.SetName("reviews")
+	split.SetNamespace("bookinfo")
+	g.Expect(unstructured.SetNestedField(split.Object, "reviews", "spec", "service")).To(gomega.Succeed())
+	g.Expect(setBackends(split, backends)).To(gomega.Succeed())
+	return append(objs, split)
+}
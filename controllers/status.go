@@ -0,0 +1,70 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// patchStatus writes the status changes made to instance during a reconcile, starting from original,
+// as a single merge patch. The patch asserts the resource version of instance so that it never overwrites
+// a status it has not seen. On conflict, the changes are applied once more on top of a fresh read,
+// unless the status itself changed meanwhile; the conflict is then returned so that the request is requeued.
+func (r *ExperimentReconciler) patchStatus(context context.Context, original, instance *iter8v1alpha1.Experiment) error {
+	if equality.Semantic.DeepEqual(original.Status, instance.Status) {
+		return nil
+	}
+
+	err := r.Status().Patch(context, instance, optimisticMergeFrom(original))
+	if err == nil || errors.IsNotFound(err) || !errors.IsConflict(err) {
+		return ignoreNotFound(err)
+	}
+
+	latest := &iter8v1alpha1.Experiment{}
+	if err := r.Get(context, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}, latest); err != nil {
+		return ignoreNotFound(err)
+	}
+	if !equality.Semantic.DeepEqual(original.Status, latest.Status) {
+		return err
+	}
+
+	patched := latest.DeepCopy()
+	patched.Status = instance.Status
+	return ignoreNotFound(r.Status().Patch(context, patched, optimisticMergeFrom(latest)))
+}
+
+// optimisticMergeFrom returns a merge patch from base that also carries the resource version of the patched object,
+// so that the API server rejects the patch with a conflict if the object changed since it was read
+func optimisticMergeFrom(base *iter8v1alpha1.Experiment) client.Patch {
+	base = base.DeepCopy()
+	base.ResourceVersion = ""
+	return client.MergeFrom(base)
+}
+
+// ignoreNotFound drops not found errors: the status of a deleted experiment need not be written
+func ignoreNotFound(err error) error {
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
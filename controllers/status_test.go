@@ -0,0 +1,43 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"encoding/json"
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOptimisticMergeFrom(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	original := &iter8v1alpha1.Experiment{
+		ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2-rollout", Namespace: "bookinfo", ResourceVersion: "42"},
+	}
+
+	instance := original.DeepCopy()
+	instance.Status.CurrentIteration = 3
+	data, err := optimisticMergeFrom(original).Data(instance)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	patch := map[string]interface{}{}
+	g.Expect(json.Unmarshal(data, &patch)).To(gomega.Succeed())
+	g.Expect(patch).To(gomega.HaveKeyWithValue("metadata", map[string]interface{}{"resourceVersion": "42"}))
+	g.Expect(patch).To(gomega.HaveKeyWithValue("status", map[string]interface{}{"currentIteration": float64(3)}))
+	g.Expect(original.ResourceVersion).To(gomega.Equal("42"))
+}
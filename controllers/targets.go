@@ -20,45 +20,68 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
 )
 
 type Targets struct {
-	Service   *corev1.Service
-	Baseline  *appsv1.Deployment
-	Candidate *appsv1.Deployment
+	Service    *corev1.Service
+	Baseline   *appsv1.Deployment
+	Candidates []*appsv1.Deployment
 }
 
 func InitTargets() *Targets {
 	return &Targets{
-		Service:   &corev1.Service{},
-		Baseline:  &appsv1.Deployment{},
-		Candidate: &appsv1.Deployment{},
+		Service:    &corev1.Service{},
+		Baseline:   &appsv1.Deployment{},
+		Candidates: []*appsv1.Deployment{},
 	}
 }
 
+// GetCandidate returns the index of the named candidate, or -1 if it is not a target
+func (t *Targets) GetCandidate(name string) int {
+	for i, candidate := range t.Candidates {
+		if candidate.GetName() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// losers returns the candidate deployments other than the winner
+func (t *Targets) losers(winner string) []runtime.Object {
+	out := make([]runtime.Object, 0, len(t.Candidates))
+	for _, candidate := range t.Candidates {
+		if candidate.GetName() != winner {
+			out = append(out, candidate)
+		}
+	}
+	return out
+}
+
 func (t *Targets) Cleanup(context context.Context, instance *iter8v1alpha1.Experiment, client client.Client) error {
 	if instance.Spec.CleanUp == iter8v1alpha1.CleanUpDelete {
 		if experimentSucceeded(instance) {
 			// experiment is successful
 			switch instance.Spec.TrafficControl.GetOnSuccess() {
 			case "candidate":
-				// delete baseline deployment
-				if err := deleteObjects(context, client, t.Baseline); err != nil {
+				// delete baseline deployment and candidates other than the winner
+				if err := deleteObjects(context, client, append(t.losers(instance.Status.Winner), t.Baseline)...); err != nil {
 					return err
 				}
 			case "both":
 				//no-op
 			case "baseline":
-				// delete candidate deployment
-				if err := deleteObjects(context, client, t.Candidate); err != nil {
+				// delete candidate deployments
+				if err := deleteObjects(context, client, t.losers("")...); err != nil {
 					return err
 				}
 			}
 		} else {
-			if err := deleteObjects(context, client, t.Candidate); err != nil {
+			if err := deleteObjects(context, client, t.losers("")...); err != nil {
 				return err
 			}
 		}
@@ -66,3 +89,36 @@ func (t *Targets) Cleanup(context context.Context, instance *iter8v1alpha1.Exper
 
 	return nil
 }
+
+// ServiceTargets are the services of an experiment whose baseline and candidates are services,
+// routed to by the backends of a traffic split or a route
+type ServiceTargets struct {
+	Service    *corev1.Service
+	Baseline   *corev1.Service
+	Candidates []*corev1.Service
+}
+
+// getServiceTargets fetches the target service and the baseline and candidate services of the experiment
+func (r *ExperimentReconciler) getServiceTargets(context context.Context, instance *iter8v1alpha1.Experiment) (*ServiceTargets, error) {
+	serviceNamespace := getServiceNamespace(instance)
+	names := append([]string{instance.Spec.TargetService.Name, instance.Spec.TargetService.Baseline},
+		instance.Spec.TargetService.GetCandidates()...)
+	services := make([]*corev1.Service, len(names))
+	for i, name := range names {
+		services[i] = &corev1.Service{}
+		if err := r.Get(context, types.NamespacedName{Name: name, Namespace: serviceNamespace}, services[i]); err != nil {
+			r.MarkTargetsError(context, instance, "Missing Service %s", name)
+			return nil, err
+		}
+	}
+	return &ServiceTargets{Service: services[0], Baseline: services[1], Candidates: services[2:]}, nil
+}
+
+// analyticsTargets returns the baseline and the candidates as given to the analytics service
+func (t *ServiceTargets) analyticsTargets() (interface{}, []interface{}) {
+	candidates := make([]interface{}, len(t.Candidates))
+	for i, candidate := range t.Candidates {
+		candidates[i] = candidate
+	}
+	return t.Baseline, candidates
+}
@@ -0,0 +1,53 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// applyTemplate merges the template referenced by the experiment into the spec held in memory, on every reconcile.
+// The merge is never written back: the stored spec keeps the fields of the experiment apart from those of the template,
+// and edits of the template reach the experiment on its next reconcile. The merged settings are validated
+// as the admission webhook validates those of the experiment. The generation of the merged template
+// is recorded in the status.
+func (r *ExperimentReconciler) applyTemplate(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	name := instance.Spec.Template
+	if name == "" {
+		return nil
+	}
+
+	template := &iter8v1alpha1.ExperimentTemplate{}
+	if err := r.Get(context, types.NamespacedName{Name: name, Namespace: instance.Namespace}, template); err != nil {
+		r.MarkTemplateError(context, instance, "Fail to get template %s: %v", name, err)
+		return err
+	}
+
+	instance.ApplyTemplate(&template.Spec)
+	if err := instance.ValidateTemplated(); err != nil {
+		r.MarkTemplateError(context, instance, "Invalid settings from template %s: %v", name, err)
+		return err
+	}
+	if generation := template.GetGeneration(); generation != instance.Status.TemplateGeneration {
+		instance.Status.TemplateGeneration = generation
+		Logger(context).Info("TemplateMerged", "template", name, "generation", generation)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// archiveStatusKey is the key of the final status in the archive config map
+const archiveStatusKey = "status.json"
+
+// checkTTL deletes the completed experiment once its time to live after completion is over,
+// archiving its final status first if requested. It returns the time left before the deletion,
+// or zero if there is no deletion to wait for.
+// The experiment is completed: the finalizer leaves the routing as it is.
+func (r *ExperimentReconciler) checkTTL(context context.Context, instance *iter8v1alpha1.Experiment) (time.Duration, error) {
+	ttl, ok := instance.Spec.GetTTLAfterFinished()
+	if !ok {
+		return 0, nil
+	}
+
+	if left := expiresIn(instance, ttl, time.Now()); left > 0 {
+		return left, nil
+	}
+
+	if instance.Spec.GetArchiveStatus() {
+		if err := r.archiveStatus(context, instance); err != nil {
+			return 0, err
+		}
+	}
+
+	reason := "ExperimentExpired"
+	Logger(context).Info(reason, "ttl", ttl.String())
+	r.recordNormalEvent(true, instance, reason, "Deleted %s after completion", ttl)
+	return 0, ignoreNotFound(r.Delete(context, instance))
+}
+
+// isCompleted tells whether the experiment is recorded as completed
+func isCompleted(instance *iter8v1alpha1.Experiment) bool {
+	completed := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionExperimentCompleted)
+	return completed != nil && completed.Status == corev1.ConditionTrue
+}
+
+// expiresIn returns the time left at now before the completed experiment is deleted
+func expiresIn(instance *iter8v1alpha1.Experiment, ttl time.Duration, now time.Time) time.Duration {
+	return completedAt(instance).Add(ttl).Sub(now)
+}
+
+// completedAt returns the end of the experiment, or the last transition of its completed condition
+// if the end timestamp is not usable
+func completedAt(instance *iter8v1alpha1.Experiment) time.Time {
+	if ms, err := strconv.ParseInt(instance.Status.EndTimestamp, 10, 64); err == nil {
+		return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+	}
+	if completed := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionExperimentCompleted); completed != nil {
+		return completed.LastTransitionTime.Inner.Time
+	}
+	return time.Time{}
+}
+
+// archiveStatus exports the final status of the experiment to a config map named after the experiment and the
+// end of the run, so that reruns of the experiment are archived side by side. The config map is not owned by the
+// experiment and outlives it.
+func (r *ExperimentReconciler) archiveStatus(context context.Context, instance *iter8v1alpha1.Experiment) error {
+	status, err := json.Marshal(instance.Status)
+	if err != nil {
+		return err
+	}
+
+	archive := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instance.Name + "-" + completedAt(instance).UTC().Format("20060102150405"),
+			Namespace: instance.Namespace,
+			Labels:    map[string]string{experimentLabel: instance.Name},
+		},
+		Data: map[string]string{archiveStatusKey: string(status)},
+	}
+	if err := r.Create(context, archive); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+	Logger(context).Info("StatusArchived", "configmap", archive.Name)
+	return nil
+}
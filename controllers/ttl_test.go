@@ -0,0 +1,47 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+	"time"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+)
+
+func TestExpiresIn(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Status.EndTimestamp = "1600000000000"
+	end := time.Unix(1600000000, 0)
+
+	g.Expect(expiresIn(instance, time.Hour, end.Add(10*time.Minute))).To(gomega.Equal(50 * time.Minute))
+	g.Expect(expiresIn(instance, time.Hour, end.Add(2*time.Hour))).To(gomega.BeNumerically("<", 0))
+
+	// without a usable end timestamp, the completed condition tells when the experiment ended
+	instance.Status.EndTimestamp = ""
+	instance.Status.InitializeConditions()
+	instance.Status.MarkExperimentCompleted()
+	completed := instance.Status.GetCondition(iter8v1alpha1.ExperimentConditionExperimentCompleted)
+	g.Expect(completedAt(instance)).To(gomega.Equal(completed.LastTransitionTime.Inner.Time))
+
+	ttl := int32(0)
+	instance.Spec.TTLSecondsAfterFinished = &ttl
+	expiry, ok := instance.Spec.GetTTLAfterFinished()
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(expiry).To(gomega.BeZero())
+}
@@ -0,0 +1,151 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"context"
+
+	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+)
+
+// targetNamespaceField indexes experiments by the namespace of their target service
+const targetNamespaceField = "spec.targetService.namespace"
+
+// watchTargets watches the target and routing objects of experiments, so that experiments react
+// as soon as their targets appear or their routing rules change instead of waiting for their next requeue.
+// Routing kinds are watched only when they are installed: a missing kind would keep the manager from starting.
+func (r *ExperimentReconciler) watchTargets(mgr manager.Manager, c controller.Controller) error {
+	if err := mgr.GetFieldIndexer().IndexField(&iter8v1alpha1.Experiment{}, targetNamespaceField,
+		func(obj runtime.Object) []string {
+			return []string{getServiceNamespace(obj.(*iter8v1alpha1.Experiment))}
+		}); err != nil {
+		return err
+	}
+
+	kinds := []runtime.Object{&appsv1.Deployment{}}
+	for _, kind := range routingKinds() {
+		gvk, err := apiutil.GVKForObject(kind, mgr.GetScheme())
+		if err != nil {
+			return err
+		}
+		if _, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version); err != nil {
+			log.Info("NoRoutingWatch", "kind", gvk.String(), "err", err)
+			continue
+		}
+		kinds = append(kinds, kind)
+	}
+
+	mapper := &handler.EnqueueRequestsFromMapFunc{ToRequests: handler.ToRequestsFunc(r.mapToExperiments)}
+	for _, kind := range kinds {
+		if err := c.Watch(&source.Kind{Type: kind}, mapper, targetChangedPredicate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// routingKinds are the kinds of the routing objects which experiments drive
+func routingKinds() []runtime.Object {
+	split := &unstructured.Unstructured{}
+	split.SetGroupVersionKind(TrafficSplitGVK)
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	return []runtime.Object{&v1alpha3.VirtualService{}, &v1alpha3.DestinationRule{}, &servingv1alpha1.Service{}, split, route}
+}
+
+// targetChangedPredicate ignores updates that only change the status of the object
+var targetChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() ||
+			!equalStringMaps(e.MetaOld.GetLabels(), e.MetaNew.GetLabels())
+	},
+}
+
+// experimentChangedPredicate ignores the updates of the experiment status, which every reconcile makes.
+// Annotations are watched too: they carry user requests such as a rerun or an approval, which leave the generation as is.
+var experimentChangedPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration() ||
+			!equalStringMaps(e.MetaOld.GetAnnotations(), e.MetaNew.GetAnnotations()) ||
+			e.MetaNew.GetDeletionTimestamp() != nil
+	},
+}
+
+// mapToExperiments returns the experiments owning the object through the experiment label.
+// Deployments are not labeled: they are mapped to the experiments naming them as baseline or candidate.
+func (r *ExperimentReconciler) mapToExperiments(a handler.MapObject) []reconcile.Request {
+	experiments := &iter8v1alpha1.ExperimentList{}
+	if err := r.List(context.Background(), experiments,
+		client.MatchingFields{targetNamespaceField: a.Meta.GetNamespace()}); err != nil {
+		log.Error(err, "FailToListExperiments")
+		return nil
+	}
+
+	_, isDeployment := a.Object.(*appsv1.Deployment)
+	owner, labeled := a.Meta.GetLabels()[experimentLabel]
+
+	requests := []reconcile.Request{}
+	for i := range experiments.Items {
+		experiment := &experiments.Items[i]
+		if (labeled && owner == experiment.Name) || (isDeployment && isTarget(experiment, a.Meta.GetName())) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: experiment.Name, Namespace: experiment.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// isTarget tells whether the named deployment is the baseline or a candidate of the experiment
+func isTarget(instance *iter8v1alpha1.Experiment, name string) bool {
+	if instance.Spec.TargetService.Baseline == name {
+		return true
+	}
+	for _, candidate := range instance.Spec.TargetService.GetCandidates() {
+		if candidate == name {
+			return true
+		}
+	}
+	return false
+}
+
+func equalStringMaps(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if v, ok := b[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,86 @@
+/*
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package experiment
+
+import (
+	"testing"
+
+	iter8v1alpha1 "github.com/iter8-tools/iter8-controller/pkg/apis/iter8/v1alpha1"
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestTargetChangedPredicate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	old := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2", Generation: 1}}
+
+	statusOnly := old.DeepCopy()
+	statusOnly.Status.ReadyReplicas = 1
+	g.Expect(targetChangedPredicate.Update(event.UpdateEvent{
+		MetaOld: old, ObjectOld: old, MetaNew: statusOnly, ObjectNew: statusOnly,
+	})).To(gomega.BeFalse())
+
+	labeled := old.DeepCopy()
+	labeled.Labels = map[string]string{experimentLabel: "reviews-v2-rollout"}
+	g.Expect(targetChangedPredicate.Update(event.UpdateEvent{
+		MetaOld: old, ObjectOld: old, MetaNew: labeled, ObjectNew: labeled,
+	})).To(gomega.BeTrue())
+
+	g.Expect(targetChangedPredicate.Create(event.CreateEvent{Meta: old, Object: old})).To(gomega.BeTrue())
+}
+
+func TestExperimentChangedPredicate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	old := &iter8v1alpha1.Experiment{ObjectMeta: metav1.ObjectMeta{Name: "reviews-v2-rollout", Generation: 1}}
+
+	statusOnly := old.DeepCopy()
+	statusOnly.Status.CurrentIteration = 1
+	g.Expect(experimentChangedPredicate.Update(event.UpdateEvent{
+		MetaOld: old, ObjectOld: old, MetaNew: statusOnly, ObjectNew: statusOnly,
+	})).To(gomega.BeFalse())
+
+	specChanged := old.DeepCopy()
+	specChanged.Generation = 2
+	g.Expect(experimentChangedPredicate.Update(event.UpdateEvent{
+		MetaOld: old, ObjectOld: old, MetaNew: specChanged, ObjectNew: specChanged,
+	})).To(gomega.BeTrue())
+
+	annotated := old.DeepCopy()
+	annotated.Annotations = map[string]string{rerunAnnotation: "1"}
+	g.Expect(experimentChangedPredicate.Update(event.UpdateEvent{
+		MetaOld: old, ObjectOld: old, MetaNew: annotated, ObjectNew: annotated,
+	})).To(gomega.BeTrue())
+
+	deleted := old.DeepCopy()
+	now := metav1.Now()
+	deleted.DeletionTimestamp = &now
+	g.Expect(experimentChangedPredicate.Update(event.UpdateEvent{
+		MetaOld: old, ObjectOld: old, MetaNew: deleted, ObjectNew: deleted,
+	})).To(gomega.BeTrue())
+}
+
+func TestIsTarget(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+	instance := &iter8v1alpha1.Experiment{}
+	instance.Spec.TargetService.Baseline = "reviews-v1"
+	instance.Spec.TargetService.Candidates = []string{"reviews-v2", "reviews-v3"}
+
+	g.Expect(isTarget(instance, "reviews-v1")).To(gomega.BeTrue())
+	g.Expect(isTarget(instance, "reviews-v3")).To(gomega.BeTrue())
+	g.Expect(isTarget(instance, "ratings-v1")).To(gomega.BeFalse())
+}
@@ -6,6 +6,7 @@ require (
 	contrib.go.opencensus.io/exporter/prometheus v0.1.0 // indirect
 	contrib.go.opencensus.io/exporter/stackdriver v0.12.8 // indirect
 	github.com/go-logr/logr v0.1.0
+	github.com/gogo/protobuf v1.3.0
 	github.com/google/go-containerregistry v0.0.0-20200104041235-b02f5c5c9053 // indirect
 	github.com/iter8-tools/iter8-controller v0.0.0-20191221011331-32b987dca6db
 	github.com/knative/pkg v0.0.0-20200109235555-79d67498c2c4
@@ -13,6 +14,7 @@ require (
 	github.com/mattbaird/jsonpatch v0.0.0-20171005235357-81af80346b1a // indirect
 	github.com/onsi/ginkgo v1.10.1
 	github.com/onsi/gomega v1.7.0
+	golang.org/x/net v0.0.0-20191004110552-13f9640d40b9
 	gopkg.in/yaml.v2 v2.2.4
 	istio.io/api v0.0.0-20200110104435-e7b15ef81473
 	istio.io/client-go v0.0.0-20200109220800-6e3ba544208e
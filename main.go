@@ -19,7 +19,10 @@ import (
 	"flag"
 	"os"
 
+	servingv1alpha1 "github.com/knative/serving/pkg/apis/serving/v1alpha1"
+	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	iter8v1alpha1 "iter8.tools/iter8-controller/api/v1alpha1"
+	iter8v1alpha2 "iter8.tools/iter8-controller/api/v1alpha2"
 	"iter8.tools/iter8-controller/controllers"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -38,21 +41,37 @@ func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 
 	_ = iter8v1alpha1.AddToScheme(scheme)
+	_ = iter8v1alpha2.AddToScheme(scheme)
+	_ = networkingv1alpha3.AddToScheme(scheme)
+	_ = servingv1alpha1.AddToScheme(scheme)
 	// +kubebuilder:scaffold:scheme
 }
 
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var experimentDefaults string
+	var maxConcurrentReconciles int
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&experimentDefaults, "experiment-defaults", "",
+		"Path to a YAML file overriding the cluster-wide defaults of experiment traffic control and analysis.")
+	flag.IntVar(&maxConcurrentReconciles, "max-concurrent-reconciles", 1,
+		"The maximum number of experiments reconciled in parallel.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(func(o *zap.Options) {
 		o.Development = true
 	}))
 
+	if experimentDefaults != "" {
+		if err := iter8v1alpha1.LoadDefaults(experimentDefaults); err != nil {
+			setupLog.Error(err, "unable to load experiment defaults", "path", experimentDefaults)
+			os.Exit(1)
+		}
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
@@ -68,10 +87,20 @@ func main() {
 		Client: mgr.GetClient(),
 		Log:    ctrl.Log.WithName("controllers").WithName("Experiment"),
 		Scheme: mgr.GetScheme(),
+
+		MaxConcurrentReconciles: maxConcurrentReconciles,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Experiment")
 		os.Exit(1)
 	}
+	if err = (&iter8v1alpha1.Experiment{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Experiment")
+		os.Exit(1)
+	}
+	if err = (&iter8v1alpha2.Experiment{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Experiment")
+		os.Exit(1)
+	}
 	// +kubebuilder:scaffold:builder
 
 	setupLog.Info("starting manager")